@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/ownership"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/proxy"
+	"github.com/huangdijia/ccswitch/internal/settings"
+)
+
+// detectActiveProfile matches the environment of a settings file against
+// every profile, ignoring keys ccswitch did not write. While a proxy serves
+// the settings, the proxied profile is compared with the environment the
+// proxy wrote instead
+func detectActiveProfile(profs *profiles.Profiles, s *settings.ClaudeSettings) *profiles.Match {
+	env := make(map[string]string, len(s.Env))
+	for k, v := range s.Env {
+		if str, ok := v.(string); ok {
+			env[k] = str
+		} else {
+			env[k] = fmt.Sprint(v)
+		}
+	}
+
+	// Keys the user set next to the profile are not drift
+	if record, err := ownership.Get(ownership.Path(profs.Path), s.Path); err == nil && record != nil {
+		for k := range env {
+			if !record.Owns(k) {
+				delete(env, k)
+			}
+		}
+	}
+
+	candidates := make(map[string]map[string]string)
+	state, err := proxy.Running(proxy.StatePath(profs.Path))
+	if err == nil && state != nil && env["ANTHROPIC_BASE_URL"] == state.URL() && profs.Has(state.Profile) {
+		candidates[state.Profile] = proxy.ClientEnv(profs.Get(state.Profile), state)
+	} else {
+		for _, name := range profs.GetAll() {
+			candidates[name] = profs.Get(name)
+			// Profiles with a token command are written without tokens
+			if resolved, err := profs.Resolve(name); err == nil && resolved.TokenCommand != nil {
+				if err := applyTokenHelperEnv(candidates[name], resolved.TokenCommand); err != nil {
+					delete(candidates, name)
+				}
+			}
+		}
+	}
+
+	return profiles.MatchEnv(env, candidates)
+}
+
+// printActiveProfile reports which profile the settings correspond to, the
+// differences if they have drifted, and when it was switched to
+func printActiveProfile(profilesPath, settingsPath string, s *settings.ClaudeSettings) {
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		return
+	}
+
+	match := detectActiveProfile(profs, s)
+	switch {
+	case match == nil && len(s.Env) == 0:
+		fmt.Println("  Active profile: (none)")
+	case match == nil:
+		fmt.Println("  Active profile: (custom, does not match any profile)")
+	case match.Exact():
+		fmt.Printf("  Active profile: %s%s\n", match.Profile, switchedSince(profilesPath, settingsPath, match.Profile))
+	default:
+		fmt.Printf("  Active profile: %s (drifted, %d difference(s))%s\n", match.Profile, len(match.Diffs), switchedSince(profilesPath, settingsPath, match.Profile))
+		printDiffs(os.Stdout, match.Diffs, "    ")
+	}
+	if note := ambiguityNote(match); note != "" {
+		fmt.Printf("    %s\n", note)
+	}
+}
+
+// switchedSince describes when profileName was last switched to in
+// settingsPath, according to the history
+func switchedSince(profilesPath, settingsPath, profileName string) string {
+	profilesPath, err := pathutil.ExpandHome(profilesPath)
+	if err != nil {
+		return ""
+	}
+	entries, err := history.Load(history.Path(profilesPath))
+	if err != nil {
+		return ""
+	}
+	current := history.Current(history.ForSettings(entries, settingsPath))
+	if current == nil || current.Profile != profileName {
+		return ""
+	}
+	return fmt.Sprintf(" (since %s)", current.Time.Local().Format("2006-01-02 15:04:05"))
+}
+
+// printDiffs prints how the settings differ from a profile, masking secrets
+func printDiffs(w io.Writer, diffs []profiles.Diff, indent string) {
+	for _, d := range diffs {
+		want, got := d.Want, d.Got
+		if output.IsSensitiveKey(d.Key) {
+			want, got = output.MaskSensitiveValue(want), output.MaskSensitiveValue(got)
+		}
+		switch {
+		case d.Want == "":
+			fmt.Fprintf(w, "%s+ %s: %s\n", indent, d.Key, got)
+		case d.Got == "":
+			fmt.Fprintf(w, "%s- %s: %s\n", indent, d.Key, want)
+		default:
+			fmt.Fprintf(w, "%s~ %s: %s (profile: %s)\n", indent, d.Key, got, want)
+		}
+	}
+}
+
+// ambiguityNote explains which other profiles match the settings as well as
+// match does, or returns "" when the match is unambiguous
+func ambiguityNote(match *profiles.Match) string {
+	if match == nil || len(match.Ambiguous) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Also matches %s; stored secrets are not compared", strings.Join(match.Ambiguous, ", "))
+}
+
+// activeProfileName returns the profile matching the settings, exactly or
+// with drift, or an empty string for custom settings
+func activeProfileName(profs *profiles.Profiles, s *settings.ClaudeSettings) string {
+	if match := detectActiveProfile(profs, s); match != nil {
+		return match.Profile
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/proxy"
+	"github.com/huangdijia/ccswitch/internal/settings"
+)
+
+func TestDetectActiveProfile(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := applyOptions{scope: settings.ScopeUser, settingsPath: settingsPath, profilesPath: profilesPath}
+	if err := applyProfile(profs, "test-profile", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := cmdutil.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := detectActiveProfile(profs, s); m == nil || m.Profile != "test-profile" || !m.Exact() {
+		t.Errorf("detectActiveProfile() = %+v, want exact test-profile", m)
+	}
+
+	s.Env["ANTHROPIC_MODEL"] = "edited-model"
+	if m := detectActiveProfile(profs, s); m == nil || m.Profile != "test-profile" || m.Exact() {
+		t.Errorf("detectActiveProfile() = %+v, want drifted test-profile", m)
+	}
+
+	t.Run("proxied profile", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		state := &proxy.State{Address: listener.Addr().String(), Profile: "another-profile"}
+		if err := state.Save(proxy.StatePath(profilesPath)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := applyProfile(profs, "another-profile", opts); err != nil {
+			t.Fatal(err)
+		}
+		s, err := cmdutil.LoadSettings(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := detectActiveProfile(profs, s); m == nil || m.Profile != "another-profile" || !m.Exact() {
+			t.Errorf("detectActiveProfile() = %+v, want exact another-profile", m)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -31,7 +32,8 @@ var addCmd = &cobra.Command{
 	Short:   "Add a new Claude API profile",
 	Long: `Add a new Claude API profile with custom configuration or install from preset profiles.
 
-Use --online flag to select from preset profiles available on GitHub.
+Use --online flag to select one or more preset profiles available on GitHub;
+press Space to choose several and Enter to install them.
 Without --online flag, you can create a custom profile by providing your own configuration.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -52,7 +54,7 @@ Without --online flag, you can create a custom profile by providing your own con
 	},
 }
 
-// installOnlineProfile handles installation of profiles from preset.json
+// installOnlineProfile handles installation of one or more profiles from preset.json
 func installOnlineProfile(cmd *cobra.Command, profs *profiles.Profiles) error {
 	// Download preset.json to temporary directory
 	tmpDir, err := os.MkdirTemp("", "ccswitch-add-online-*")
@@ -89,19 +91,13 @@ func installOnlineProfile(cmd *cobra.Command, profs *profiles.Profiles) error {
 	sort.Strings(profileNames)
 
 	// Interactive profile selection
-	inFile, inOK := cmd.InOrStdin().(*os.File)
-	outFile, outOK := cmd.OutOrStdout().(*os.File)
-	if !inOK || !outOK {
-		return fmt.Errorf("interactive mode requires an interactive terminal on stdin and stdout")
-	}
-
-	selected, err := termui.SelectString(termui.SelectConfig{
-		In:           inFile,
-		Out:          outFile,
-		Prompt:       "Select profile to add:",
-		Hint:         "↑/↓ to move, Enter to select, q to cancel",
+	selected, err := selectItems(cmd, termui.SelectConfig{
+		Prompt:       "Select profiles to add:",
 		Items:        profileNames,
 		DefaultIndex: 0,
+		Details: func(name string) string {
+			return profileDetails(presetConfig.Descriptions[name], presetConfig.Profiles[name])
+		},
 	})
 	if err != nil {
 		if err == termui.ErrCanceled {
@@ -111,62 +107,66 @@ func installOnlineProfile(cmd *cobra.Command, profs *profiles.Profiles) error {
 		return err
 	}
 
-	profileName := selected
-
-	// Check if profile already exists
-	if profs.Has(profileName) && !addForce {
-		return fmt.Errorf("profile '%s' already exists. Use --force to overwrite", profileName)
-	}
-
-	// Get the profile from preset config
-	selectedProfile := presetConfig.Profiles[profileName]
-	description := presetConfig.Descriptions[profileName]
-
-	// Make a copy of the profile
-	env := make(map[string]string)
-	for k, v := range selectedProfile {
-		env[k] = v
+	// Check if profiles already exist
+	if !addForce {
+		for _, profileName := range selected {
+			if profs.Has(profileName) {
+				return fmt.Errorf("profile '%s' already exists. Use --force to overwrite", profileName)
+			}
+		}
 	}
 
-	// Prompt for authentication token
-	fmt.Printf("\nEnter authentication token for profile '%s'", profileName)
-	if authKey, ok := env["ANTHROPIC_AUTH_TOKEN"]; ok && authKey != "" {
-		fmt.Printf(" [current: %s]", output.MaskSensitiveValue(authKey))
-	}
-	fmt.Print(": ")
+	reader := bufio.NewReader(cmd.InOrStdin())
+	var added []map[string]string
+	for _, profileName := range selected {
+		// Get the profile from preset config
+		selectedProfile := presetConfig.Profiles[profileName]
 
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return fmt.Errorf("failed to read authentication token: %w", err)
-	}
-	authToken := strings.TrimSpace(input)
+		// Make a copy of the profile
+		env := make(map[string]string)
+		for k, v := range selectedProfile {
+			env[k] = v
+		}
 
-	// Update the auth token if provided
-	if authToken != "" {
-		env["ANTHROPIC_AUTH_TOKEN"] = authToken
-	}
+		// Prompt for authentication token
+		fmt.Printf("\nEnter authentication token for profile '%s'", profileName)
+		if authKey, ok := env["ANTHROPIC_AUTH_TOKEN"]; ok && authKey != "" {
+			fmt.Printf(" [current: %s]", output.MaskSensitiveValue(authKey))
+		}
+		fmt.Print(": ")
 
-	// If overwriting, remove the old profile first
-	if addForce && profs.Has(profileName) {
-		delete(profs.Data.Profiles, profileName)
-		delete(profs.Data.Descriptions, profileName)
-	}
+		input, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || input == "") {
+			return fmt.Errorf("failed to read authentication token: %w", err)
+		}
+		authToken := strings.TrimSpace(input)
 
-	// Add the profile
-	if err := profs.Add(profileName, env, description); err != nil {
-		return err
+		// Update the auth token if provided
+		if authToken != "" {
+			env["ANTHROPIC_AUTH_TOKEN"] = authToken
+		}
+		added = append(added, env)
 	}
 
-	// Save the profiles
-	if err := profs.Save(); err != nil {
+	// Add the profiles once every token has been entered
+	err = cmdutil.UpdateProfiles(profs.Path, func(profs *profiles.Profiles) error {
+		for i, profileName := range selected {
+			if err := addProfile(profs, profileName, added[i], presetConfig.Descriptions[profileName]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	output.Success("Profile '%s' added successfully!", profileName)
+	for i, profileName := range selected {
+		output.Success("Profile '%s' added successfully!", profileName)
 
-	// Show profile details
-	output.PrintProfileDetails(env)
+		// Show profile details
+		output.PrintProfileDetails(added[i])
+	}
 
 	return nil
 }
@@ -196,12 +196,6 @@ func addCustomProfile(cmd *cobra.Command, args []string, profs *profiles.Profile
 		return fmt.Errorf("profile '%s' already exists. Use --force to overwrite", profileName)
 	}
 
-	// If overwriting, remove the old profile first
-	if profs.Has(profileName) && addForce {
-		delete(profs.Data.Profiles, profileName)
-		delete(profs.Data.Descriptions, profileName)
-	}
-
 	// Determine if we're in interactive mode (no flags provided)
 	reader := bufio.NewReader(os.Stdin)
 
@@ -285,13 +279,10 @@ func addCustomProfile(cmd *cobra.Command, args []string, profs *profiles.Profile
 	env["ANTHROPIC_DEFAULT_SONNET_MODEL"] = "sonnet"
 	env["ANTHROPIC_SMALL_FAST_MODEL"] = "haiku"
 
-	// Add the profile (now guaranteed not to exist due to earlier checks)
-	if err := profs.Add(profileName, env, description); err != nil {
-		return err
-	}
-
-	// Save the profiles
-	if err := profs.Save(); err != nil {
+	err := cmdutil.UpdateProfiles(profs.Path, func(profs *profiles.Profiles) error {
+		return addProfile(profs, profileName, env, description)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -303,6 +294,21 @@ func addCustomProfile(cmd *cobra.Command, args []string, profs *profiles.Profile
 	return nil
 }
 
+// addProfile adds a profile, replacing an existing one only with --force.
+// Tokens are moved into the secret store when one is configured
+func addProfile(profs *profiles.Profiles, profileName string, env map[string]string, description string) error {
+	if profs.Has(profileName) {
+		if !addForce {
+			return fmt.Errorf("profile '%s' already exists. Use --force to overwrite", profileName)
+		}
+		profs.Delete(profileName)
+	}
+	if err := cmdutil.StoreSecrets(profs, profileName, env); err != nil {
+		return err
+	}
+	return profs.Add(profileName, env, description)
+}
+
 func init() {
 	addCmd.Flags().BoolVarP(&addOnline, "online", "o", false, "Install a profile from online preset configuration")
 	addCmd.Flags().StringVarP(&addAPIKey, "api-key", "k", "", "Anthropic API key (for custom profiles)")
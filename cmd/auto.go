@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/pin"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/huangdijia/ccswitch/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// PinEnvVar is set by shell activation to the pin file that selected the profile
+	PinEnvVar = "CCSWITCH_PIN"
+	// PinKeysEnvVar lists the variables overridden by that pin file, comma separated
+	PinKeysEnvVar = "CCSWITCH_PIN_KEYS"
+)
+
+var (
+	autoShell string
+	autoScope string
+)
+
+var autoCmd = &cobra.Command{
+	Use:   "auto",
+	Short: "Apply the profile pinned for the current directory",
+	Long: `Apply the profile pinned by the nearest .ccswitch or .ccswitch.json file,
+searching from the current directory up to the enclosing git worktree root,
+or up to the home directory outside worktrees.
+
+A .ccswitch file contains the profile name on its first line, optionally
+followed by KEY=VALUE overrides. A .ccswitch.json file has the form:
+  {"profile": "glm", "env": {"API_TIMEOUT_MS": "600000"}}
+
+Without --shell the profile is written to the Claude settings file like
+'ccswitch use'. With --shell the command prints statements for the shell hook
+installed by 'ccswitch shell-init --auto': it activates the pinned profile when
+entering a pinned directory and deactivates it when leaving.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		p, err := pin.Find(cwd)
+		if err != nil {
+			return err
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		if autoShell != "" {
+			sh, err := shell.Parse(autoShell)
+			if err != nil {
+				return err
+			}
+			script, err := autoShellScript(cmd.Context(), sh, profs, p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), script)
+			return nil
+		}
+
+		if p == nil {
+			return fmt.Errorf("no .ccswitch file found in %s or its parents", cwd)
+		}
+
+		scope, err := settings.ParseScope(autoScope)
+		if err != nil {
+			return err
+		}
+		if err := cmdutil.ValidateProfile(profs, p.Profile); err != nil {
+			return err
+		}
+
+		fmt.Printf("Using profile '%s' pinned by %s\n", p.Profile, p.Path)
+
+		return applyProfile(profs, p.Profile, applyOptions{
+			scope:        scope,
+			settingsPath: settingsPath,
+			profilesPath: profilesPath,
+			overrides:    p.Env,
+		})
+	},
+}
+
+// autoShellScript returns the statements the shell hook evaluates: nothing
+// when the pinned profile is already active, an activation when a different
+// pin applies, and a deactivation when leaving a pinned directory
+func autoShellScript(ctx context.Context, sh shell.Shell, profs *profiles.Profiles, p *pin.Pin) (string, error) {
+	activePin := os.Getenv(PinEnvVar)
+
+	if p == nil {
+		if activePin == "" || os.Getenv(ProfileEnvVar) == "" {
+			return "", nil
+		}
+		return deactivationScript(sh, profs, os.Getenv(ProfileEnvVar))
+	}
+
+	if p.Path == activePin && p.Profile == os.Getenv(ProfileEnvVar) {
+		return "", nil
+	}
+
+	extra := map[string]string{PinEnvVar: p.Path}
+	keys := make([]string, 0, len(p.Env))
+	for k, v := range p.Env {
+		extra[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		extra[PinKeysEnvVar] = strings.Join(keys, ",")
+	}
+	return activationScript(ctx, sh, profs, p.Profile, extra)
+}
+
+func init() {
+	autoCmd.Flags().StringVar(&autoShell, "shell", "", "Print statements for the given shell instead of writing settings")
+	autoCmd.Flags().StringVar(&autoScope, "scope", string(settings.ScopeUser), "Settings scope to write: user, project or local")
+}
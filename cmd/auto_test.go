@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestAutoCommand(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	projectDir := t.TempDir()
+	pinPath := filepath.Join(projectDir, ".ccswitch")
+	if err := os.WriteFile(pinPath, []byte("another-profile\nAPI_TIMEOUT_MS=600000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	originalWd, _ := os.Getwd()
+	os.Chdir(projectDir)
+	defer os.Chdir(originalWd)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(autoCmd)
+
+	run := func(args ...string) (string, error) {
+		autoShell = ""
+		autoScope = "user"
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(append([]string{"auto", "-p", profilesPath, "-s", settingsPath}, args...))
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("writes pinned profile with overrides", func(t *testing.T) {
+		if _, err := run(); err != nil {
+			t.Fatalf("auto command failed: %v", err)
+		}
+
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatalf("Failed to read settings: %v", err)
+		}
+		var settings map[string]any
+		if err := json.Unmarshal(data, &settings); err != nil {
+			t.Fatalf("Failed to parse settings: %v", err)
+		}
+		if settings["model"] != "another-model" {
+			t.Errorf("model = %v, want %v", settings["model"], "another-model")
+		}
+		env := settings["env"].(map[string]any)
+		if env["API_TIMEOUT_MS"] != "600000" {
+			t.Errorf("API_TIMEOUT_MS = %v, want %v", env["API_TIMEOUT_MS"], "600000")
+		}
+	})
+
+	t.Run("shell hook activates pinned profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "")
+		t.Setenv(PinEnvVar, "")
+		out, err := run("--shell", "bash")
+		if err != nil {
+			t.Fatalf("auto --shell failed: %v", err)
+		}
+		if !strings.Contains(out, "export CCSWITCH_PROFILE='another-profile'") {
+			t.Errorf("output should activate the pinned profile:\n%s", out)
+		}
+		if !strings.Contains(out, "export CCSWITCH_PIN=") {
+			t.Errorf("output should record the pin file:\n%s", out)
+		}
+		if !strings.Contains(out, "export CCSWITCH_PIN_KEYS='API_TIMEOUT_MS'") {
+			t.Errorf("output should record the pin overrides:\n%s", out)
+		}
+	})
+
+	t.Run("shell hook is silent when already active", func(t *testing.T) {
+		realPin, _ := filepath.EvalSymlinks(pinPath)
+		cwd, _ := os.Getwd()
+		t.Setenv(ProfileEnvVar, "another-profile")
+		t.Setenv(PinEnvVar, filepath.Join(cwd, filepath.Base(realPin)))
+		out, err := run("--shell", "bash")
+		if err != nil {
+			t.Fatalf("auto --shell failed: %v", err)
+		}
+		if out != "" {
+			t.Errorf("output = %q, want empty", out)
+		}
+	})
+
+	t.Run("shell hook deactivates outside pinned directory", func(t *testing.T) {
+		os.Chdir(t.TempDir())
+		defer os.Chdir(projectDir)
+		t.Setenv(ProfileEnvVar, "another-profile")
+		t.Setenv(PinEnvVar, pinPath)
+		out, err := run("--shell", "bash")
+		if err != nil {
+			t.Fatalf("auto --shell failed: %v", err)
+		}
+		if !strings.Contains(out, "unset CCSWITCH_PIN") || !strings.Contains(out, "unset CCSWITCH_PROFILE") {
+			t.Errorf("output should deactivate the profile:\n%s", out)
+		}
+	})
+
+	t.Run("shell hook unsets pin overrides", func(t *testing.T) {
+		os.Chdir(t.TempDir())
+		defer os.Chdir(projectDir)
+		t.Setenv(ProfileEnvVar, "another-profile")
+		t.Setenv(PinEnvVar, pinPath)
+		t.Setenv(PinKeysEnvVar, "API_TIMEOUT_MS")
+		out, err := run("--shell", "bash")
+		if err != nil {
+			t.Fatalf("auto --shell failed: %v", err)
+		}
+		for _, key := range []string{"API_TIMEOUT_MS", "CCSWITCH_PIN_KEYS"} {
+			if !strings.Contains(out, "unset "+key+"\n") {
+				t.Errorf("output should unset %s:\n%s", key, out)
+			}
+		}
+	})
+
+	t.Run("shell hook unsets overrides of the previous pin", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "another-profile")
+		t.Setenv(PinEnvVar, filepath.Join(t.TempDir(), ".ccswitch"))
+		t.Setenv(PinKeysEnvVar, "API_TIMEOUT_MS,DISABLE_TELEMETRY")
+		out, err := run("--shell", "bash")
+		if err != nil {
+			t.Fatalf("auto --shell failed: %v", err)
+		}
+		if !strings.Contains(out, "unset DISABLE_TELEMETRY\n") {
+			t.Errorf("output should unset the previous override:\n%s", out)
+		}
+		if strings.Contains(out, "unset API_TIMEOUT_MS") {
+			t.Errorf("output should keep the override the new pin sets:\n%s", out)
+		}
+	})
+	t.Run("shell hook refuses unsafe overrides", func(t *testing.T) {
+		unsafeDir := t.TempDir()
+		os.Chdir(unsafeDir)
+		defer os.Chdir(projectDir)
+		t.Setenv(ProfileEnvVar, "")
+		t.Setenv(PinEnvVar, "")
+		for _, content := range []string{
+			"another-profile\nX;echo PWNED;Y=1\n",
+			"another-profile\nANTHROPIC_BASE_URL=https://evil.example\n",
+		} {
+			if err := os.WriteFile(filepath.Join(unsafeDir, ".ccswitch"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			out, err := run("--shell", "bash")
+			if err == nil || strings.Contains(out, "export") {
+				t.Errorf("auto --shell = %q, %v, want an error and no statements for %q", out, err, content)
+			}
+		}
+	})
+}
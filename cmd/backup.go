@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "List and restore backups of the profiles and settings files",
+	Long: `Every time ccswitch replaces the profiles file or a Claude settings file, the
+previous content is saved in the backups directory next to the profiles file.
+The newest backups of each file are kept.`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available backups, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := backupDir(cmd.Flag("profiles").Value.String())
+		if err != nil {
+			return err
+		}
+
+		backups, err := atomicfile.ListBackups(dir)
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Println("No backups found")
+			return nil
+		}
+
+		fmt.Printf("%-36s  %-19s  %8s  %s\n", "ID", "TIME", "SIZE", "FILE")
+		for _, b := range backups {
+			fmt.Printf("%-36s  %-19s  %8d  %s\n", b.ID, b.Time.Local().Format("2006-01-02 15:04:05"), len(b.Data), b.Path)
+		}
+		return nil
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore a file from a backup",
+	Long:  "Restore a file to the content saved in a backup. The current content is backed up first, so a restore can be undone.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := backupDir(cmd.Flag("profiles").Value.String())
+		if err != nil {
+			return err
+		}
+
+		b, err := atomicfile.LoadBackup(dir, args[0])
+		if err != nil {
+			return err
+		}
+
+		atomicfile.BackupDir = dir
+		if err := b.Restore(); err != nil {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+
+		output.Success("Restored %s from backup %s", b.Path, b.ID)
+		return nil
+	},
+}
+
+// backupDir returns the backups directory that belongs to the profiles file
+func backupDir(profilesPath string) (string, error) {
+	profilesPath, err := pathutil.ExpandHome(profilesPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(profilesPath), "backups"), nil
+}
+
+func init() {
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestBackupCommand(t *testing.T) {
+	tmpDir, profilesPath, settingsPath := setupTestEnvironment(t)
+	dir := filepath.Join(tmpDir, "backups")
+	atomicfile.BackupDir = dir
+	t.Cleanup(func() { atomicfile.BackupDir = "" })
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(backupCmd)
+	useScope = ""
+	useFallback = false
+
+	for _, profile := range []string{"test-profile", "another-profile"} {
+		rootCmd.SetArgs([]string{"use", profile, "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"backup", "list", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("backup list failed: %v", err)
+	}
+
+	backups, err := atomicfile.ListBackups(dir)
+	if err != nil || len(backups) == 0 {
+		t.Fatalf("ListBackups() = %v, %v, want the settings before the second switch", backups, err)
+	}
+
+	rootCmd.SetArgs([]string{"backup", "restore", backups[0].ID, "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("backup restore failed: %v", err)
+	}
+
+	s, err := cmdutil.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Env["ANTHROPIC_BASE_URL"]; got != "https://api.test.com" {
+		t.Errorf("ANTHROPIC_BASE_URL = %v, want the restored test-profile value", got)
+	}
+
+	rootCmd.SetArgs([]string{"backup", "restore", "missing", "-p", profilesPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unknown backup id, got nil")
+	}
+}
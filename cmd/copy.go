@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+var copyCmd = &cobra.Command{
+	Use:     "copy <profile> <new-name>",
+	Aliases: []string{"cp"},
+	Short:   "Copy a profile under a new name",
+	Long:    "Copy a profile's environment, description, other metadata and stored secrets to a new profile.",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		err := cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			if err := cmdutil.ValidateProfile(profs, args[0]); err != nil {
+				return err
+			}
+			keys := profs.OwnSecrets(args[0])
+			store, err := cmdutil.OpenSecretStoreFor(profs, keys)
+			if err != nil {
+				return err
+			}
+			if err := profs.Copy(args[0], args[1]); err != nil {
+				return err
+			}
+			return cmdutil.CopySecrets(store, args[0], args[1], keys)
+		})
+		if err != nil {
+			return err
+		}
+
+		output.Success("Profile '%s' copied to '%s'", args[0], args[1])
+		return nil
+	},
+}
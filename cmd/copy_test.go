@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestCopyCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(copyCmd)
+
+	rootCmd.SetArgs([]string{"copy", "test-profile", "copied", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("copy command failed: %v", err)
+	}
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profs.Has("test-profile") || !profs.Has("copied") {
+		t.Fatal("profile was not copied")
+	}
+	if got := profs.Get("copied")["ANTHROPIC_MODEL"]; got != "test-model" {
+		t.Errorf("copied ANTHROPIC_MODEL = %v, want test-model", got)
+	}
+
+	rootCmd.SetArgs([]string{"copy", "missing", "other", "-p", profilesPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error when copying an unknown profile, got nil")
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+var setDefaultCmd = &cobra.Command{
+	Use:   "set-default <profile>",
+	Short: "Set the default profile",
+	Long:  "Set the profile that is marked as the default in list and preselected by interactive commands.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		err := cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			if err := cmdutil.ValidateProfile(profs, args[0]); err != nil {
+				return err
+			}
+			return profs.SetDefault(args[0])
+		})
+		if err != nil {
+			return err
+		}
+
+		output.Success("Default profile set to '%s'", args[0])
+		return nil
+	},
+}
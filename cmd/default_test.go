@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestSetDefaultCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(setDefaultCmd)
+
+	rootCmd.SetArgs([]string{"set-default", "another-profile", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("set-default command failed: %v", err)
+	}
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profs.Default() != "another-profile" {
+		t.Errorf("Default() = %v, want another-profile", profs.Default())
+	}
+
+	rootCmd.SetArgs([]string{"set-default", "missing", "-p", profilesPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unknown profile, got nil")
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffJSON     bool
+	diffExitCode bool
+)
+
+// settingsModelKey is the pseudo key used to compare the settings "model" field
+const settingsModelKey = "model"
+
+// envChange is one difference reported by diff
+type envChange struct {
+	Key string `json:"key"`
+	// Change is added, removed or changed
+	Change string `json:"change"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// envDiff is the result of comparing two environments
+type envDiff struct {
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Changes []envChange `json:"changes"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <profile> [<profile>]",
+	Short: "Show the differences between two profiles or a profile and the settings",
+	Long: `Show what changes when switching: with two profiles, the differences between
+the first and the second resolved profile; with one profile, the differences
+between the current Claude settings and that profile. Keys you set in the
+settings yourself are kept when switching, so they are only listed where the
+profile replaces them.
+
+Sensitive values are masked unless --reveal is passed. --json is short for
+--output json. With --exit-code the command exits with status 1
+when there are differences, which is useful in scripts.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
+
+		format, err := resultFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if diffJSON {
+			format = &output.Format{Name: output.FormatJSON}
+		}
+		reveal := revealSecrets(cmd)
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			if err := cmdutil.ValidateProfile(profs, name); err != nil {
+				return err
+			}
+		}
+
+		var result *envDiff
+		if len(args) == 2 {
+			result = diffEnvs(args[0], profs.Get(args[0]), args[1], profs.Get(args[1]), false, reveal)
+		} else {
+			effective, err := cmdutil.EffectiveSettings(settingsPath, profilesPath)
+			if err != nil {
+				return err
+			}
+			// Switching keeps what the user set, so the profile is compared
+			// with the settings as use would leave them: without what
+			// ccswitch wrote before and with the profile's keys on top
+			switched, err := cmdutil.LoadSettings(effective.Path)
+			if err != nil {
+				return err
+			}
+			removeOwned(switched, appliedRecord(profilesPath, profs, switched))
+			target := settingsEnv(switched)
+			env := profs.Get(args[0])
+			for k, v := range env {
+				target[k] = v
+			}
+			if model := env["ANTHROPIC_MODEL"]; model != "" {
+				target[settingsModelKey] = model
+			}
+			result = diffEnvs(effective.Path, settingsEnv(effective.Settings), args[0], target, true, reveal)
+		}
+
+		if format.Structured() {
+			if result.Changes == nil {
+				result.Changes = []envChange{}
+			}
+			if err := output.Write(cmd.OutOrStdout(), format, result); err != nil {
+				return err
+			}
+		} else {
+			printEnvDiff(cmd, result)
+		}
+
+		if diffExitCode && len(result.Changes) > 0 {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &exitCodeError{code: 1}
+		}
+		return nil
+	},
+}
+
+// diffEnvs lists the changes from one environment to another with sensitive
+// values masked unless reveal is set. When the source holds resolved secrets, secret references
+// in the target match any value
+func diffEnvs(fromName string, from map[string]string, toName string, to map[string]string, resolvedFrom, reveal bool) *envDiff {
+	result := &envDiff{From: fromName, To: toName}
+
+	for _, d := range profiles.CompareEnv(to, from) {
+		if resolvedFrom && secrets.IsRef(d.Want) && d.Got != "" {
+			continue
+		}
+
+		change := envChange{Key: d.Key, From: displayValue(d.Key, d.Got, reveal), To: displayValue(d.Key, d.Want, reveal)}
+		switch {
+		case d.Got == "":
+			change.Change = "added"
+		case d.Want == "":
+			change.Change = "removed"
+		default:
+			change.Change = "changed"
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	return result
+}
+
+// settingsEnv returns the env of s with its model under settingsModelKey
+func settingsEnv(s *settings.ClaudeSettings) map[string]string {
+	env := make(map[string]string, len(s.Env)+1)
+	for k, v := range s.Env {
+		env[k] = fmt.Sprint(v)
+	}
+	if s.Model != "" {
+		env[settingsModelKey] = s.Model
+	}
+	return env
+}
+
+func maskDiffValue(key, value string) string {
+	if value == "" || !output.IsSensitiveKey(key) || secrets.IsRef(value) {
+		return value
+	}
+	return output.MaskSensitiveValue(value)
+}
+
+func printEnvDiff(cmd *cobra.Command, result *envDiff) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "--- %s\n", result.From)
+	fmt.Fprintf(out, "+++ %s\n", result.To)
+	if len(result.Changes) == 0 {
+		fmt.Fprintln(out, "No differences")
+		return
+	}
+
+	for _, c := range result.Changes {
+		switch c.Change {
+		case "added":
+			fmt.Fprintf(out, "+ %s: %s\n", c.Key, c.To)
+		case "removed":
+			fmt.Fprintf(out, "- %s: %s\n", c.Key, c.From)
+		default:
+			fmt.Fprintf(out, "~ %s: %s -> %s\n", c.Key, c.From, c.To)
+		}
+	}
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the differences as JSON")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 when there are differences")
+}
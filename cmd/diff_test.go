@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+func TestDiffCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+	profilesConfig := map[string]any{
+		"settingsPath": settingsPath,
+		"profiles": map[string]any{
+			"glm": map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://glm.example.com",
+				"ANTHROPIC_AUTH_TOKEN": "sk-glm-1234567890",
+				"ANTHROPIC_MODEL":      "GLM-4.6",
+				"API_TIMEOUT_MS":       "3000000",
+			},
+			"kimi": map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://kimi.example.com",
+				"ANTHROPIC_AUTH_TOKEN": "sk-kimi-abcdefghijkl",
+				"ANTHROPIC_MODEL":      "GLM-4.6",
+			},
+		},
+	}
+	profilesData, _ := json.MarshalIndent(profilesConfig, "", "    ")
+	if err := os.WriteFile(profilesPath, profilesData, 0644); err != nil {
+		t.Fatalf("Failed to write profiles config: %v", err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(diffCmd)
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(append(args, "-p", profilesPath, "-s", settingsPath))
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+	reset := func() {
+		diffJSON = false
+		diffExitCode = false
+	}
+
+	t.Run("two profiles as text", func(t *testing.T) {
+		reset()
+		out, err := run(t, "diff", "glm", "kimi")
+		if err != nil {
+			t.Fatalf("diff command failed: %v", err)
+		}
+		for _, want := range []string{
+			"--- glm",
+			"+++ kimi",
+			"~ ANTHROPIC_BASE_URL: https://glm.example.com -> https://kimi.example.com",
+			"- API_TIMEOUT_MS: 3000000",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "1234567890") || strings.Contains(out, "abcdefghijkl") {
+			t.Errorf("output leaks a token:\n%s", out)
+		}
+		if strings.Contains(out, "ANTHROPIC_MODEL") {
+			t.Errorf("output lists an unchanged key:\n%s", out)
+		}
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		reset()
+		diffJSON = true
+		out, err := run(t, "diff", "glm", "kimi")
+		if err != nil {
+			t.Fatalf("diff command failed: %v", err)
+		}
+		var result envDiff
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out)
+		}
+		changes := map[string]string{}
+		for _, c := range result.Changes {
+			changes[c.Key] = c.Change
+		}
+		if changes["API_TIMEOUT_MS"] != "removed" || changes["ANTHROPIC_AUTH_TOKEN"] != "changed" {
+			t.Errorf("changes = %v", changes)
+		}
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		reset()
+		diffExitCode = true
+		_, err := run(t, "diff", "glm", "kimi")
+		var exitErr *exitCodeError
+		if !errors.As(err, &exitErr) || exitErr.code != 1 {
+			t.Errorf("diff --exit-code error = %v, want exit status 1", err)
+		}
+
+		if _, err := run(t, "diff", "glm", "glm"); err != nil {
+			t.Errorf("diff --exit-code of identical profiles error = %v, want nil", err)
+		}
+	})
+
+	t.Run("profile against settings", func(t *testing.T) {
+		reset()
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := applyOptions{scope: settings.ScopeUser, settingsPath: settingsPath, profilesPath: profilesPath}
+		if err := applyProfile(profs, "glm", opts); err != nil {
+			t.Fatal(err)
+		}
+
+		diffExitCode = true
+		if out, err := run(t, "diff", "glm"); err != nil {
+			t.Errorf("diff against matching settings error = %v\n%s", err, out)
+		}
+
+		diffExitCode = false
+		out, err := run(t, "diff", "kimi")
+		if err != nil {
+			t.Fatalf("diff command failed: %v", err)
+		}
+		if !strings.Contains(out, "--- "+settingsPath) || !strings.Contains(out, "- API_TIMEOUT_MS: 3000000") {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+	})
+
+	t.Run("user keys in settings are not drift", func(t *testing.T) {
+		reset()
+		s, err := cmdutil.LoadSettings(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Env["DISABLE_TELEMETRY"] = "1"
+		if err := s.Write(); err != nil {
+			t.Fatal(err)
+		}
+
+		diffExitCode = true
+		if out, err := run(t, "diff", "glm"); err != nil {
+			t.Errorf("diff with a user key error = %v\n%s", err, out)
+		}
+		diffExitCode = false
+	})
+}
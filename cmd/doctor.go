@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration for problems",
+	Long: `Check ccs.json, every profile and the Claude settings file for problems
+and print how to fix them.
+
+The checks cover JSON syntax, the default profile, unknown or misspelled
+variables, invalid URLs and numbers, empty or placeholder tokens such as "sk-",
+and files holding plaintext tokens that other users can read.
+
+Exits with status 1 if any errors are found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
+
+		report := &doctorReport{out: cmd.OutOrStdout()}
+		profs := checkProfilesFile(report, profilesPath)
+		if profs != nil {
+			checkSettingsFile(report, profs, cmdutil.ResolveSettingsPath(settingsPath, profilesPath))
+		}
+
+		fmt.Fprintln(report.out)
+		if report.errors == 0 && report.warnings == 0 {
+			fmt.Fprintln(report.out, "✓ No problems found")
+			return nil
+		}
+		fmt.Fprintf(report.out, "%d error(s), %d warning(s)\n", report.errors, report.warnings)
+		if report.errors > 0 {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &exitCodeError{code: 1}
+		}
+		return nil
+	},
+}
+
+// doctorReport prints check results and counts the problems found
+type doctorReport struct {
+	out      io.Writer
+	errors   int
+	warnings int
+}
+
+func (r *doctorReport) section(format string, args ...any) {
+	fmt.Fprintf(r.out, format+"\n", args...)
+}
+
+func (r *doctorReport) ok(format string, args ...any) {
+	fmt.Fprintf(r.out, "  ✓ "+format+"\n", args...)
+}
+
+func (r *doctorReport) issue(issue profiles.Issue) {
+	if issue.Severity == profiles.SeverityError {
+		r.errors++
+	} else {
+		r.warnings++
+	}
+	printIssues(r.out, []profiles.Issue{issue}, "  ")
+}
+
+// printIssues prints validation issues with their suggested fixes
+func printIssues(w io.Writer, issues []profiles.Issue, indent string) {
+	for _, issue := range issues {
+		mark := "!"
+		if issue.Severity == profiles.SeverityError {
+			mark = "✗"
+		}
+		prefix := ""
+		if issue.Profile != "" {
+			prefix = issue.Profile + ": "
+		}
+		fmt.Fprintf(w, "%s%s %s%s\n", indent, mark, prefix, issue)
+		if issue.Fix != "" {
+			fmt.Fprintf(w, "%s    fix: %s\n", indent, issue.Fix)
+		}
+	}
+}
+
+// checkProfilesFile checks ccs.json and every profile in it. It returns nil
+// when the file cannot be loaded
+func checkProfilesFile(r *doctorReport, profilesPath string) *profiles.Profiles {
+	path, err := pathutil.ExpandHome(profilesPath)
+	if err != nil {
+		path = profilesPath
+	}
+	r.section("Profiles (%s)", path)
+
+	if !pathutil.FileExists(path) {
+		r.issue(profiles.Issue{Severity: profiles.SeverityError, Message: "file not found", Fix: "ccswitch init"})
+		return nil
+	}
+	profs, err := cmdutil.LoadProfiles(path)
+	if err != nil {
+		r.issue(profiles.Issue{
+			Severity: profiles.SeverityError,
+			Message:  fmt.Sprintf("cannot be parsed: %v", err),
+			Fix:      fmt.Sprintf("fix the JSON in %s or restore it with 'ccswitch backup list'", path),
+		})
+		return nil
+	}
+	r.ok("%d profile(s) loaded", len(profs.Data.Profiles))
+
+	switch {
+	case profs.Data.Default == "":
+		r.issue(profiles.Issue{Severity: profiles.SeverityWarning, Message: "no default profile set", Fix: "ccswitch set-default <profile>"})
+	case !profs.Has(profs.Data.Default):
+		r.issue(profiles.Issue{
+			Severity: profiles.SeverityError,
+			Message:  fmt.Sprintf("default profile '%s' does not exist", profs.Data.Default),
+			Fix:      "ccswitch set-default <profile>",
+		})
+	default:
+		r.ok("default profile '%s' exists", profs.Data.Default)
+	}
+
+	issues := profs.ValidateAll()
+	for _, issue := range issues {
+		r.issue(issue)
+	}
+	if len(issues) == 0 {
+		r.ok("all profiles are valid")
+	}
+
+	plaintext := false
+	for _, env := range profs.Data.Profiles {
+		plaintext = plaintext || cmdutil.HasPlaintextSecrets(env)
+	}
+	checkPermissions(r, path, plaintext, "ccswitch secrets migrate")
+
+	switch profs.Data.SecretsBackend {
+	case "":
+	case secrets.BackendVault:
+		if vault := cmdutil.VaultPath(path); pathutil.FileExists(vault) {
+			checkPermissions(r, vault, true, "")
+		}
+	case secrets.BackendSecretService:
+	default:
+		r.issue(profiles.Issue{
+			Severity: profiles.SeverityError,
+			Key:      "secretsBackend",
+			Message:  fmt.Sprintf("unknown secrets backend '%s'", profs.Data.SecretsBackend),
+			Fix:      fmt.Sprintf("set \"secretsBackend\" to %q or %q in %s", secrets.BackendVault, secrets.BackendSecretService, path),
+		})
+	}
+
+	return profs
+}
+
+// checkSettingsFile checks the Claude settings file and reports which
+// profile it holds
+func checkSettingsFile(r *doctorReport, profs *profiles.Profiles, settingsPath string) {
+	path, err := pathutil.ExpandHome(settingsPath)
+	if err != nil {
+		path = settingsPath
+	}
+	fmt.Fprintln(r.out)
+	r.section("Settings (%s)", path)
+
+	if !pathutil.FileExists(path) {
+		r.issue(profiles.Issue{Severity: profiles.SeverityWarning, Message: "file does not exist yet", Fix: "ccswitch use <profile>"})
+		return
+	}
+	s, err := settings.New(path)
+	if err != nil {
+		r.issue(profiles.Issue{
+			Severity: profiles.SeverityError,
+			Message:  fmt.Sprintf("cannot be parsed: %v", err),
+			Fix:      fmt.Sprintf("fix the JSON in %s or restore it with 'ccswitch backup list'", path),
+		})
+		return
+	}
+
+	env := make(map[string]string, len(s.Env))
+	for k, v := range s.Env {
+		if str, ok := v.(string); ok {
+			env[k] = str
+		} else {
+			env[k] = fmt.Sprint(v)
+		}
+	}
+
+	fix := "ccswitch use <profile>"
+	match := detectActiveProfile(profs, s)
+	switch {
+	case match == nil && len(env) == 0:
+		r.ok("no profile applied")
+	case match == nil:
+		r.issue(profiles.Issue{Severity: profiles.SeverityWarning, Message: "env does not match any profile", Fix: fix})
+	case match.Exact():
+		r.ok("matches profile '%s'", match.Profile)
+		fix = "ccswitch use " + match.Profile
+	default:
+		fix = "ccswitch use " + match.Profile
+		r.issue(profiles.Issue{
+			Severity: profiles.SeverityWarning,
+			Message:  fmt.Sprintf("drifted from profile '%s' (%d difference(s))", match.Profile, len(match.Diffs)),
+			Fix:      fix,
+		})
+	}
+	if note := ambiguityNote(match); note != "" {
+		r.issue(profiles.Issue{Severity: profiles.SeverityWarning, Message: note, Fix: fix})
+	}
+
+	issues := profiles.ValidateEnv(env)
+	for _, key := range secrets.Keys {
+		if value, ok := env[key]; ok && output.IsEmptyToken(value) {
+			issues = append(issues, profiles.Issue{
+				Key:      key,
+				Severity: profiles.SeverityError,
+				Message:  fmt.Sprintf("token is empty or only the placeholder %q", value),
+			})
+		}
+	}
+	for _, issue := range issues {
+		issue.Fix = fix
+		r.issue(issue)
+	}
+	if len(issues) == 0 {
+		r.ok("env is valid")
+	}
+
+	checkPermissions(r, path, cmdutil.HasPlaintextSecrets(env), "")
+}
+
+// checkPermissions warns when a file holding plaintext tokens can be read by
+// other users. Permissions are not checked on Windows
+func checkPermissions(r *doctorReport, path string, sensitive bool, alternative string) {
+	if !sensitive || runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	mode := info.Mode().Perm()
+	if mode&0077 == 0 {
+		r.ok("permissions %04o", mode)
+		return
+	}
+
+	fix := "chmod 600 " + path
+	if alternative != "" {
+		fix += " or " + alternative
+	}
+	r.issue(profiles.Issue{
+		Severity: profiles.SeverityWarning,
+		Message:  fmt.Sprintf("holds plaintext tokens and is readable by other users (mode %04o)", mode),
+		Fix:      fix,
+	})
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "ccs.json")
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(doctorCmd)
+
+	run := func(t *testing.T) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"doctor", "-p", profilesPath, "-s", settingsPath})
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	writeFile := func(t *testing.T, path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("healthy configuration", func(t *testing.T) {
+		writeFile(t, profilesPath, `{
+			"default": "glm",
+			"profiles": {"glm": {"ANTHROPIC_BASE_URL": "https://api.example.com", "ANTHROPIC_AUTH_TOKEN": "sk-real-token"}}
+		}`)
+		writeFile(t, settingsPath, `{"env": {"ANTHROPIC_BASE_URL": "https://api.example.com", "ANTHROPIC_AUTH_TOKEN": "sk-real-token"}}`)
+
+		out, err := run(t)
+		if err != nil {
+			t.Fatalf("doctor failed: %v\n%s", err, out)
+		}
+		if !strings.Contains(out, "matches profile 'glm'") || !strings.Contains(out, "No problems found") {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+	})
+
+	t.Run("problems are reported with fixes", func(t *testing.T) {
+		writeFile(t, profilesPath, `{
+			"default": "missing",
+			"profiles": {"glm": {"ANTHROPIC_BASE_ULR": "https://api.example.com", "ANTHROPIC_AUTH_TOKEN": "sk-"}}
+		}`)
+		writeFile(t, settingsPath, `{"env": {"API_TIMEOUT_MS": "later"}}`)
+
+		out, err := run(t)
+		var exitErr *exitCodeError
+		if !errors.As(err, &exitErr) || exitErr.code != 1 {
+			t.Fatalf("doctor error = %v, want exit status 1", err)
+		}
+		for _, want := range []string{
+			"default profile 'missing' does not exist",
+			"glm: ANTHROPIC_BASE_ULR: unknown variable, did you mean ANTHROPIC_BASE_URL?",
+			"fix: ccswitch set glm --stdin ANTHROPIC_AUTH_TOKEN",
+			"API_TIMEOUT_MS must be a non-negative integer",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("unparsable profiles", func(t *testing.T) {
+		writeFile(t, profilesPath, `{"profiles": `)
+
+		out, err := run(t)
+		if err == nil || !strings.Contains(out, "cannot be parsed") {
+			t.Errorf("doctor error = %v, output:\n%s", err, out)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <profile>",
+	Short: "Edit a profile in your editor",
+	Long: `Open a profile as JSON in $VISUAL or $EDITOR, including its description,
+extends, fallbacks and modelMap. The profile is validated when the editor is
+closed; if it is invalid you can edit it again or abort without changes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		profileName := args[0]
+		if err := cmdutil.RequireProfile(profs, profileName); err != nil {
+			return err
+		}
+
+		original, err := profs.Definition(profileName)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(original, "", "    ")
+		if err != nil {
+			return err
+		}
+
+		tmp, err := os.CreateTemp("", "ccswitch-"+profileName+"-*.json")
+		if err != nil {
+			return err
+		}
+		tmpPath := tmp.Name()
+		defer os.Remove(tmpPath)
+		_, err = tmp.Write(append(data, '\n'))
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		reader := bufio.NewReader(cmd.InOrStdin())
+		var edited []byte
+		for {
+			if err := editFile(tmpPath); err != nil {
+				return fmt.Errorf("editor failed: %w", err)
+			}
+
+			edited, err = os.ReadFile(tmpPath)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(bytes.TrimSpace(edited), data) {
+				fmt.Println("No changes")
+				return nil
+			}
+
+			err = applyEditedProfile(profs, profileName, original, edited)
+			if err == nil {
+				break
+			}
+
+			output.Error("%v", err)
+			fmt.Print("Edit again? [Y/n]: ")
+			answer, readErr := reader.ReadString('\n')
+			if readErr != nil || strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+				return fmt.Errorf("profile '%s' was not changed", profileName)
+			}
+		}
+
+		// The profiles are not locked while the editor is open, so the edit
+		// is applied again under the lock unless the profile changed meanwhile
+		err = cmdutil.UpdateProfiles(profilesPath, func(latest *profiles.Profiles) error {
+			current, err := latest.Definition(profileName)
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(current, original) {
+				return fmt.Errorf("profile '%s' was changed while it was being edited; run edit again", profileName)
+			}
+			if err := applyEditedProfile(latest, profileName, current, edited); err != nil {
+				return err
+			}
+			// Move tokens typed into the editor into the secret store
+			return cmdutil.StoreSecrets(latest, profileName, latest.Data.Profiles[profileName])
+		})
+		if err != nil {
+			return err
+		}
+
+		output.Success("Profile '%s' updated", profileName)
+		return nil
+	},
+}
+
+// applyEditedProfile parses an edited profile and stores it, restoring the
+// original definition if the result does not validate
+func applyEditedProfile(profs *profiles.Profiles, profileName string, original *profiles.Definition, data []byte) error {
+	var def profiles.Definition
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&def); err != nil {
+		return fmt.Errorf("invalid profile JSON: %w", err)
+	}
+	if def.Env == nil {
+		return fmt.Errorf("invalid profile: \"env\" is required")
+	}
+
+	profs.Put(profileName, &def)
+	if err := profs.ValidateProfile(profileName); err != nil {
+		profs.Put(profileName, original)
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+	return nil
+}
+
+// editFile opens path in the user's editor and waits for it to exit
+var editFile = func(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestEditCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	originalEditFile := editFile
+	t.Cleanup(func() { editFile = originalEditFile })
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(editCmd)
+
+	// edits replays the given file contents, one per editor invocation
+	edits := func(contents ...string) {
+		editFile = func(path string) error {
+			content := contents[0]
+			contents = contents[1:]
+			return os.WriteFile(path, []byte(content), 0644)
+		}
+	}
+
+	t.Run("valid edit", func(t *testing.T) {
+		edits(`{"description": "Edited", "extends": "another-profile", "env": {"ANTHROPIC_BASE_URL": "https://edited"}}`)
+		rootCmd.SetIn(strings.NewReader(""))
+		rootCmd.SetArgs([]string{"edit", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("edit command failed: %v", err)
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := profs.Get("test-profile")
+		if env["ANTHROPIC_BASE_URL"] != "https://edited" || env["ANTHROPIC_MODEL"] != "another-model" {
+			t.Errorf("edited env = %v", env)
+		}
+		if profs.Data.Descriptions["test-profile"] != "Edited" {
+			t.Errorf("description = %v, want Edited", profs.Data.Descriptions["test-profile"])
+		}
+	})
+
+	t.Run("invalid edit is retried", func(t *testing.T) {
+		edits(`{"env": `, `{"extends": "missing", "env": {}}`, `{"env": {"ANTHROPIC_MODEL": "retried"}}`)
+		rootCmd.SetIn(strings.NewReader("y\n\n"))
+		rootCmd.SetArgs([]string{"edit", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("edit command failed: %v", err)
+		}
+
+		profs, _ := cmdutil.LoadProfiles(profilesPath)
+		if got := profs.Get("test-profile")["ANTHROPIC_MODEL"]; got != "retried" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want retried", got)
+		}
+	})
+
+	t.Run("aborted edit leaves profile unchanged", func(t *testing.T) {
+		edits(`{"unknown": true, "env": {}}`)
+		rootCmd.SetIn(strings.NewReader("n\n"))
+		rootCmd.SetArgs([]string{"edit", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for aborted edit, got nil")
+		}
+
+		profs, _ := cmdutil.LoadProfiles(profilesPath)
+		if got := profs.Get("test-profile")["ANTHROPIC_MODEL"]; got != "retried" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want unchanged", got)
+		}
+	})
+}
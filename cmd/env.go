@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var (
+	envShell      string
+	envUnset      bool
+	shellInitAuto bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [profile]",
+	Short: "Print shell statements that activate a profile",
+	Long: `Print shell statements that export a profile's environment variables.
+
+Evaluate the output to activate a profile in the current shell only:
+  eval "$(ccswitch env glm)"                          # bash, zsh
+  ccswitch env glm --shell fish | source              # fish
+  ccswitch env glm --shell powershell | Invoke-Expression
+
+Use --unset to print statements that remove the active profile's variables.
+See 'ccswitch shell-init' to install a 'ccs' shell function that does this for you.`,
+	Args: cobra.MaximumNArgs(1),
+	// Usage text must never end up in the output evaluated by the shell
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		sh := shell.Detect()
+		if envShell != "" {
+			var err error
+			if sh, err = shell.Parse(envShell); err != nil {
+				return err
+			}
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		if envUnset {
+			profileName := os.Getenv(ProfileEnvVar)
+			if len(args) > 0 {
+				profileName = args[0]
+			}
+			if profileName == "" {
+				return fmt.Errorf("no active profile in this shell (%s is not set)", ProfileEnvVar)
+			}
+			script, err := deactivationScript(sh, profs, profileName)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), script)
+			return nil
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("no profile specified (use 'ccswitch env <profile>')")
+		}
+
+		script, err := activationScript(cmd.Context(), sh, profs, args[0], nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), script)
+
+		return nil
+	},
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init [bash|zsh|fish|powershell]",
+	Short: "Print the shell integration script",
+	Long: `Print a script that defines the 'ccs' shell function, which activates
+a profile in the current shell: 'ccs glm' exports the profile and
+'ccs --unset' removes it again.
+
+With --auto, the script also installs a hook that runs 'ccswitch auto'
+whenever the current directory changes, activating the profile pinned by
+the nearest .ccswitch or .ccswitch.json file.
+
+Add one of the following to your shell startup file:
+  eval "$(ccswitch shell-init bash)"                  # ~/.bashrc
+  eval "$(ccswitch shell-init zsh)"                   # ~/.zshrc
+  ccswitch shell-init fish | source                   # ~/.config/fish/config.fish
+  ccswitch shell-init powershell | Out-String | Invoke-Expression   # $PROFILE`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sh := shell.Detect()
+		if len(args) > 0 {
+			var err error
+			if sh, err = shell.Parse(args[0]); err != nil {
+				return err
+			}
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			executable = "ccswitch"
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), shell.Init(sh, executable))
+		if shellInitAuto {
+			fmt.Fprint(cmd.OutOrStdout(), shell.Hook(sh, executable))
+		}
+
+		return nil
+	},
+}
+
+// activationScript returns shell statements that export a profile's
+// environment, with extra variables applied on top, and unset the variables
+// of the previously activated profile that the new one does not set
+func activationScript(ctx context.Context, sh shell.Shell, profs *profiles.Profiles, profileName string, extra map[string]string) (string, error) {
+	// Errors must not be printed to stdout, which is evaluated by the shell
+	if !profs.Has(profileName) {
+		return "", fmt.Errorf("profile '%s' not found", profileName)
+	}
+	resolved, err := profs.Resolve(profileName)
+	if err != nil {
+		return "", err
+	}
+
+	env := profs.Get(profileName)
+	for k, v := range extra {
+		env[k] = v
+	}
+	if err := applyToken(ctx, profs, profileName, resolved.TokenCommand, env); err != nil {
+		return "", err
+	}
+	env, err = cmdutil.ResolveSecrets(profs, env)
+	if err != nil {
+		return "", err
+	}
+	env[ProfileEnvVar] = profileName
+
+	script := ""
+	if stale := staleKeys(profs, os.Getenv(ProfileEnvVar), env); len(stale) > 0 {
+		if script, err = shell.Unset(sh, stale); err != nil {
+			return "", err
+		}
+	}
+	exports, err := shell.Export(sh, env)
+	if err != nil {
+		return "", err
+	}
+	return script + exports, nil
+}
+
+// deactivationScript returns shell statements that unset a profile's variables
+// together with the variables ccswitch sets to track activation
+func deactivationScript(sh shell.Shell, profs *profiles.Profiles, profileName string) (string, error) {
+	keys := append([]string{ProfileEnvVar}, pinKeys()...)
+	for key := range profs.Get(profileName) {
+		keys = append(keys, key)
+	}
+	return shell.Unset(sh, keys)
+}
+
+// pinKeys returns the variables set by the active pin: the pin tracking
+// variables and the overrides listed in PinKeysEnvVar
+func pinKeys() []string {
+	var keys []string
+	for _, key := range []string{PinEnvVar, PinKeysEnvVar} {
+		if os.Getenv(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range strings.Split(os.Getenv(PinKeysEnvVar), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// staleKeys returns the variables set by the previous profile and the active
+// pin that are not part of env
+func staleKeys(profs *profiles.Profiles, previous string, env map[string]string) []string {
+	previousKeys := pinKeys()
+	if previous != "" && profs.Has(previous) {
+		for key := range profs.Get(previous) {
+			previousKeys = append(previousKeys, key)
+		}
+	}
+
+	var stale []string
+	seen := make(map[string]bool)
+	for _, key := range previousKeys {
+		if _, ok := env[key]; !ok && !seen[key] {
+			seen[key] = true
+			stale = append(stale, key)
+		}
+	}
+	return stale
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envShell, "shell", "", "Shell dialect: bash, zsh, fish or powershell (default: detected from $SHELL)")
+	envCmd.Flags().BoolVarP(&envUnset, "unset", "u", false, "Print statements that unset the profile's variables")
+	shellInitCmd.Flags().BoolVar(&shellInitAuto, "auto", false, "Also activate pinned profiles when changing directory")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEnvCommand(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(envCmd)
+
+	run := func(args ...string) (string, error) {
+		envShell = ""
+		envUnset = false
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(append([]string{"env", "-p", profilesPath}, args...))
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("bash exports", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "")
+		out, err := run("test-profile", "--shell", "bash")
+		if err != nil {
+			t.Fatalf("env command failed: %v", err)
+		}
+		for _, want := range []string{
+			"export ANTHROPIC_BASE_URL='https://api.test.com'\n",
+			"export ANTHROPIC_DEFAULT_OPUS_MODEL='test-model'\n",
+			"export CCSWITCH_PROFILE='test-profile'\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "unset") {
+			t.Errorf("output should not unset anything without an active profile:\n%s", out)
+		}
+	})
+
+	t.Run("switching unsets stale keys", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "test-profile")
+		out, err := run("another-profile", "--shell", "bash")
+		if err != nil {
+			t.Fatalf("env command failed: %v", err)
+		}
+		if !strings.Contains(out, "unset ANTHROPIC_BASE_URL\n") {
+			t.Errorf("output should unset ANTHROPIC_BASE_URL:\n%s", out)
+		}
+	})
+
+	t.Run("unset active profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "test-profile")
+		out, err := run("--unset", "--shell", "fish")
+		if err != nil {
+			t.Fatalf("env --unset failed: %v", err)
+		}
+		for _, want := range []string{"set -e ANTHROPIC_BASE_URL;\n", "set -e CCSWITCH_PROFILE;\n"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("unknown profile prints nothing to stdout", func(t *testing.T) {
+		out, err := run("missing", "--shell", "bash")
+		if err == nil {
+			t.Error("Expected error for unknown profile, got nil")
+		}
+		if out != "" {
+			t.Errorf("stdout = %q, want empty", out)
+		}
+	})
+}
+
+func TestShellInitCommand(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.AddCommand(shellInitCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"shell-init", "zsh"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("shell-init failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "ccs()") || !strings.Contains(out.String(), "env --shell zsh") {
+		t.Errorf("shell-init output = %q, want a ccs function", out.String())
+	}
+}
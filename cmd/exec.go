@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// ProfileEnvVar is set in child processes to the name of the active profile
+const ProfileEnvVar = "CCSWITCH_PROFILE"
+
+// exitCodeError carries the exit status of a child process so that Execute
+// can exit with the same code
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec <profile> -- <command> [args...]",
+	Short: "Run a command with a profile's environment",
+	Long: `Run a single command with a profile's environment variables injected,
+leaving the Claude settings file untouched. This allows several providers to be
+used side by side in different terminals.
+
+Example:
+  ccswitch exec glm -- claude
+  ccswitch exec deepseek -- claude -p "explain this repo"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		profileName := args[0]
+		if err := cmdutil.ValidateProfile(profs, profileName); err != nil {
+			return err
+		}
+
+		resolved, err := profs.Resolve(profileName)
+		if err != nil {
+			return err
+		}
+		env := profs.Get(profileName)
+		if err := applyToken(cmd.Context(), profs, profileName, resolved.TokenCommand, env); err != nil {
+			return err
+		}
+		env, err = cmdutil.ResolveSecrets(profs, env)
+		if err != nil {
+			return err
+		}
+		env[ProfileEnvVar] = profileName
+
+		// With interspersed flags disabled the "--" separator reaches us as an argument
+		command := args[1:]
+		if command[0] == "--" {
+			command = command[1:]
+		}
+		if len(command) == 0 {
+			return fmt.Errorf("no command specified")
+		}
+
+		child := exec.Command(command[0], command[1:]...)
+		child.Env = mergeEnv(os.Environ(), env)
+		child.Stdin = cmd.InOrStdin()
+		child.Stdout = cmd.OutOrStdout()
+		child.Stderr = cmd.ErrOrStderr()
+
+		if err := child.Start(); err != nil {
+			return fmt.Errorf("failed to start %s: %w", command[0], err)
+		}
+
+		// Forward signals to the child instead of letting them terminate ccswitch
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+		go func() {
+			for sig := range signals {
+				_ = child.Process.Signal(sig)
+			}
+		}()
+
+		err = child.Wait()
+		signal.Stop(signals)
+		close(signals)
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &exitCodeError{code: exitErr.ExitCode()}
+		}
+		return err
+	},
+}
+
+// mergeEnv overlays env on top of base, a list of KEY=VALUE pairs as returned
+// by os.Environ. Overridden entries are dropped from base.
+func mergeEnv(base []string, env map[string]string) []string {
+	result := make([]string, 0, len(base)+len(env))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := env[key]; ok {
+			continue
+		}
+		result = append(result, kv)
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result = append(result, k+"="+env[k])
+	}
+
+	return result
+}
+
+func init() {
+	// Stop flag parsing at the first positional argument so that the
+	// command's own flags are passed through even without "--".
+	execCmd.Flags().SetInterspersed(false)
+}
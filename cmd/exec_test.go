@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestExecHelperProcess is not a real test; it is the child process started
+// by the exec command tests. It writes selected variables to a file and exits
+// with the requested code.
+func TestExecHelperProcess(t *testing.T) {
+	if os.Getenv("CCSWITCH_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	out := os.Getenv("CCSWITCH_HELPER_OUTPUT")
+	content := os.Getenv("ANTHROPIC_BASE_URL") + "\n" + os.Getenv(ProfileEnvVar) + "\n"
+	os.WriteFile(out, []byte(content), 0644)
+	if os.Getenv("CCSWITCH_HELPER_FAIL") == "1" {
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func TestExecCommand(t *testing.T) {
+	tmpDir, profilesPath, settingsPath := setupTestEnvironment(t)
+	outputPath := filepath.Join(tmpDir, "child-output")
+
+	t.Setenv("CCSWITCH_WANT_HELPER_PROCESS", "1")
+	t.Setenv("CCSWITCH_HELPER_OUTPUT", outputPath)
+	t.Setenv("ANTHROPIC_BASE_URL", "https://from-parent.example.com")
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(execCmd)
+
+	helperArgs := []string{os.Args[0], "-test.run=TestExecHelperProcess"}
+
+	t.Run("injects profile environment", func(t *testing.T) {
+		rootCmd.SetArgs(append([]string{"exec", "-p", profilesPath, "test-profile", "--"}, helperArgs...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("exec command failed: %v", err)
+		}
+
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read child output: %v", err)
+		}
+		lines := strings.Split(string(data), "\n")
+		if lines[0] != "https://api.test.com" {
+			t.Errorf("child ANTHROPIC_BASE_URL = %v, want %v", lines[0], "https://api.test.com")
+		}
+		if lines[1] != "test-profile" {
+			t.Errorf("child %s = %v, want %v", ProfileEnvVar, lines[1], "test-profile")
+		}
+
+		if _, err := os.Stat(settingsPath); err == nil {
+			t.Error("exec should not write the settings file")
+		}
+	})
+
+	t.Run("propagates exit code", func(t *testing.T) {
+		t.Setenv("CCSWITCH_HELPER_FAIL", "1")
+		rootCmd.SetArgs(append([]string{"exec", "-p", profilesPath, "test-profile", "--"}, helperArgs...))
+		err := rootCmd.Execute()
+
+		var exitErr *exitCodeError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("exec error = %v, want exitCodeError", err)
+		}
+		if exitErr.code != 3 {
+			t.Errorf("exit code = %v, want %v", exitErr.code, 3)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		rootCmd.SetArgs(append([]string{"exec", "-p", profilesPath, "missing", "--"}, helperArgs...))
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for unknown profile, got nil")
+		}
+	})
+}
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"PATH=/bin", "ANTHROPIC_MODEL=old", "EMPTY="}
+	got := mergeEnv(base, map[string]string{"ANTHROPIC_MODEL": "new", "API_TIMEOUT_MS": "1000"})
+
+	want := []string{"PATH=/bin", "EMPTY=", "ANTHROPIC_MODEL=new", "API_TIMEOUT_MS=1000"}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("mergeEnv() = %v, want %v", got, want)
+	}
+}
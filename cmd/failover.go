@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/probe"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var (
+	failoverTimeout time.Duration
+	failoverLogPath string
+)
+
+var failoverCmd = &cobra.Command{
+	Use:   "failover [profile]",
+	Short: "Switch to a healthy fallback when the active profile is down",
+	Long: `Probe the active profile's endpoint and, if it is unhealthy, switch the
+Claude settings file to the first healthy profile listed in its fallbacks:
+
+  "fallbacks": {
+      "anyrouter": ["glm", "deepseek"]
+  }
+
+The active profile is detected from the settings file unless given as an
+argument. Every action is logged with a timestamp, so the command can be run
+from cron; use --log to also append the log to a file. The command exits
+non-zero when neither the profile nor any fallback is healthy.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
+
+		var logOutput io.Writer = cmd.OutOrStdout()
+		if failoverLogPath != "" {
+			logFile, err := os.OpenFile(failoverLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open log file: %w", err)
+			}
+			defer logFile.Close()
+			logOutput = io.MultiWriter(logOutput, logFile)
+		}
+		logger := log.New(logOutput, "", log.LstdFlags)
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		settingsPath = cmdutil.ResolveSettingsPath(settingsPath, profilesPath)
+
+		var current string
+		if len(args) > 0 {
+			current = args[0]
+		} else {
+			currentSettings, err := cmdutil.LoadSettings(settingsPath)
+			if err != nil {
+				return err
+			}
+			if current = activeProfileName(profs, currentSettings); current == "" {
+				return fmt.Errorf("cannot determine the active profile from %s (pass it as an argument)", settingsPath)
+			}
+		}
+		if err := cmdutil.ValidateProfile(profs, current); err != nil {
+			return err
+		}
+
+		client := &http.Client{Timeout: failoverTimeout}
+		healthy, err := firstHealthyProfile(cmd.Context(), client, profs, current, logger)
+		if err != nil {
+			logger.Printf("failover: %v", err)
+			cmd.SilenceUsage = true
+			return err
+		}
+		if healthy == current {
+			return nil
+		}
+
+		if err := applyProfile(profs, healthy, applyOptions{
+			scope:        settings.ScopeUser,
+			settingsPath: settingsPath,
+			profilesPath: profilesPath,
+		}); err != nil {
+			return err
+		}
+		logger.Printf("failover: switched from %s to %s", current, healthy)
+
+		return nil
+	},
+}
+
+// firstHealthyProfile probes name and then each of its fallbacks in order,
+// returning the first profile whose endpoint answers successfully
+func firstHealthyProfile(ctx context.Context, client *http.Client, profs *profiles.Profiles, name string, logger *log.Logger) (string, error) {
+	resolver := cmdutil.NewSecretResolver(profs)
+	candidates := append([]string{name}, profs.Data.Fallbacks[name]...)
+	seen := make(map[string]bool)
+
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		resolved, err := profs.Resolve(candidate)
+		if err != nil {
+			logger.Printf("failover: skipping %s: %v", candidate, err)
+			continue
+		}
+		env := profs.Get(candidate)
+		if err := applyToken(ctx, profs, candidate, resolved.TokenCommand, env); err != nil {
+			logger.Printf("failover: skipping %s: %v", candidate, err)
+			continue
+		}
+		env, err = resolver.Resolve(env)
+		if err != nil {
+			logger.Printf("failover: skipping %s: %v", candidate, err)
+			continue
+		}
+
+		result := probe.Health(ctx, client, env)
+		if result.OK() {
+			logger.Printf("failover: %s is healthy (%s)", candidate, result.Describe())
+			return candidate, nil
+		}
+		logger.Printf("failover: %s is unhealthy (%s)", candidate, result.Describe())
+	}
+
+	if len(candidates) == 1 {
+		return "", fmt.Errorf("%s is unhealthy and has no fallbacks configured", name)
+	}
+	return "", fmt.Errorf("%s and all of its fallbacks are unhealthy", name)
+}
+
+func init() {
+	failoverCmd.Flags().DurationVarP(&failoverTimeout, "timeout", "t", 30*time.Second, "Timeout for each health check")
+	failoverCmd.Flags().StringVar(&failoverLogPath, "log", "", "Also append the log to this file")
+}
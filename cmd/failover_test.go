@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestFailoverCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"type":"message"}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+	profilesConfig := map[string]any{
+		"settingsPath": settingsPath,
+		"profiles": map[string]any{
+			"primary":  map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "down", "ANTHROPIC_MODEL": "primary-model"},
+			"broken":   map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "down", "ANTHROPIC_MODEL": "broken-model"},
+			"backup":   map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "good", "ANTHROPIC_MODEL": "backup-model"},
+			"isolated": map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "down", "ANTHROPIC_MODEL": "isolated-model"},
+		},
+		"fallbacks": map[string]any{
+			"primary": []string{"broken", "backup"},
+		},
+	}
+	profilesData, _ := json.MarshalIndent(profilesConfig, "", "    ")
+	if err := os.WriteFile(profilesPath, profilesData, 0644); err != nil {
+		t.Fatalf("Failed to write profiles config: %v", err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", "", "settings path")
+	rootCmd.AddCommand(failoverCmd)
+	rootCmd.AddCommand(useCmd)
+
+	reset := func() {
+		failoverTimeout = 5 * time.Second
+		failoverLogPath = ""
+		useFallback = false
+		useScope = ""
+		os.Remove(settingsPath)
+	}
+	t.Cleanup(reset)
+
+	currentModel := func(t *testing.T) string {
+		t.Helper()
+		s, err := cmdutil.LoadSettings(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, _ := s.Env["ANTHROPIC_MODEL"].(string)
+		return model
+	}
+
+	t.Run("switches to first healthy fallback", func(t *testing.T) {
+		reset()
+		logPath := filepath.Join(tmpDir, "failover.log")
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"failover", "primary", "--log", logPath, "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("failover command failed: %v", err)
+		}
+		if got := currentModel(t); got != "backup-model" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want backup-model", got)
+		}
+
+		logData, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"primary is unhealthy", "broken is unhealthy", "switched from primary to backup"} {
+			if !strings.Contains(string(logData), want) {
+				t.Errorf("log missing %q:\n%s", want, logData)
+			}
+		}
+	})
+
+	t.Run("detects active profile from settings", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"use", "primary", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		rootCmd.SetArgs([]string{"failover", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("failover command failed: %v", err)
+		}
+		if got := currentModel(t); got != "backup-model" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want backup-model", got)
+		}
+	})
+
+	t.Run("fails without healthy fallback", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"failover", "isolated", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error when no fallback is healthy, got nil")
+		}
+	})
+
+	t.Run("use with fallback", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"use", "primary", "--fallback", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use --fallback failed: %v", err)
+		}
+		if got := currentModel(t); got != "backup-model" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want backup-model", got)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of profile switches",
+	Long:  "Show the profiles activated by use and reset, newest first. Use 'ccswitch use -' to switch back to the previous profile.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath, err := pathutil.ExpandHome(cmd.Flag("profiles").Value.String())
+		if err != nil {
+			return err
+		}
+
+		entries, err := history.Load(history.Path(profilesPath))
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No history yet")
+			return nil
+		}
+
+		fmt.Printf("%-19s  %-20s  %-7s  %s\n", "TIME", "PROFILE", "SCOPE", "SETTINGS")
+		for i, shown := len(entries)-1, 0; i >= 0; i-- {
+			if historyLimit > 0 && shown == historyLimit {
+				break
+			}
+			shown++
+
+			e := entries[i]
+			profile := e.Profile
+			if profile == "" {
+				profile = "(reset)"
+			}
+			fmt.Printf("%-19s  %-20s  %-7s  %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), profile, e.Scope, e.SettingsPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of entries to show (0 for all)")
+}
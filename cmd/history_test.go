@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func TestUsePrevious(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(historyCmd)
+	useScope = ""
+	useFallback = false
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+		rootCmd.SetArgs(append(args, "-p", profilesPath, "-s", settingsPath))
+		return rootCmd.Execute()
+	}
+	currentModel := func(t *testing.T) string {
+		t.Helper()
+		s, err := cmdutil.LoadSettings(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Model
+	}
+
+	if err := run(t, "use", "-"); err == nil {
+		t.Error("Expected error for use - without history, got nil")
+	}
+
+	for _, profile := range []string{"test-profile", "another-profile"} {
+		if err := run(t, "use", profile); err != nil {
+			t.Fatalf("use %s failed: %v", profile, err)
+		}
+	}
+
+	if err := run(t, "use", "-"); err != nil {
+		t.Fatalf("use - failed: %v", err)
+	}
+	if got := currentModel(t); got != "test-model" {
+		t.Errorf("model after use - = %v, want test-model", got)
+	}
+
+	if err := run(t, "use", "-"); err != nil {
+		t.Fatalf("use - failed: %v", err)
+	}
+	if got := currentModel(t); got != "another-model" {
+		t.Errorf("model after second use - = %v, want another-model", got)
+	}
+
+	if err := run(t, "reset"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if err := run(t, "history"); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+
+	entries, err := history.Load(history.Path(profilesPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 || entries[4].Profile != "" || entries[0].Scope != "user" {
+		t.Errorf("history = %v, want four switches and a reset", entries)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
 	"github.com/huangdijia/ccswitch/internal/httputil"
 	"github.com/huangdijia/ccswitch/internal/output"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
@@ -90,7 +91,7 @@ var initCmd = &cobra.Command{
 		}
 
 		// Write configuration file
-		if err := os.WriteFile(profilesPath, configContent, 0644); err != nil {
+		if err := atomicfile.WriteFile(profilesPath, configContent, 0644); err != nil {
 			return fmt.Errorf("failed to write configuration file: %w", err)
 		}
 
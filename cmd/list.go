@@ -1,73 +1,240 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listSort    string
+	listColumns []string
+)
+
+// listColumn is a column the list table can show
+type listColumn struct {
+	Name   string
+	Header string
+	Value  func(p output.ProfileSummary) string
+}
+
+var listTableColumns = []listColumn{
+	{"profile", "Profile", func(p output.ProfileSummary) string { return p.Name }},
+	{"description", "Description", func(p output.ProfileSummary) string { return p.Description }},
+	{"url", "URL", func(p output.ProfileSummary) string { return p.URL }},
+	{"model", "Model", func(p output.ProfileSummary) string { return p.Model }},
+	{"active", "Active", func(p output.ProfileSummary) string {
+		switch {
+		case p.Drifted:
+			return "Drifted"
+		case p.Active:
+			return "Active"
+		}
+		return ""
+	}},
+	{"status", "Status", func(p output.ProfileSummary) string {
+		if p.Default {
+			return "Default"
+		}
+		return ""
+	}},
+	{"last-used", "Last used", func(p output.ProfileSummary) string {
+		if p.LastUsed == nil {
+			return ""
+		}
+		return p.LastUsed.Local().Format("2006-01-02 15:04")
+	}},
+}
+
+// defaultListColumns are shown when --columns is not given
+var defaultListColumns = []string{"profile", "description", "url", "model", "active", "status"}
+
+// listSorts orders profiles for --sort; ties are broken by name
+var listSorts = map[string]func(a, b output.ProfileSummary) int{
+	"name":  func(a, b output.ProfileSummary) int { return 0 },
+	"url":   func(a, b output.ProfileSummary) int { return cmp.Compare(a.URL, b.URL) },
+	"model": func(a, b output.ProfileSummary) int { return cmp.Compare(a.Model, b.Model) },
+	// Most recently used first, never used last
+	"last-used": func(a, b output.ProfileSummary) int {
+		switch {
+		case a.LastUsed == nil && b.LastUsed == nil:
+			return 0
+		case a.LastUsed == nil:
+			return 1
+		case b.LastUsed == nil:
+			return -1
+		}
+		return b.LastUsed.Compare(*a.LastUsed)
+	},
+}
+
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls", "profiles"},
 	Short:   "List all available profiles",
-	Long:    "This command allows you to list all configured Claude API profiles",
+	Long: `This command allows you to list all configured Claude API profiles.
+
+Profiles are sorted with --sort: name (the default), url, model or last-used.
+--columns chooses the table columns from profile, description, url, model,
+active, status and last-used. The table is fitted to the terminal width.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
 
-		profs, err := cmdutil.LoadProfiles(profilesPath)
+		format, err := resultFormat(cmd)
+		if err != nil {
+			return err
+		}
+		sortBy, ok := listSorts[listSort]
+		if !ok {
+			return fmt.Errorf("unknown sort '%s': expected name, url, model or last-used", listSort)
+		}
+		columns, err := selectListColumns(listColumns)
 		if err != nil {
 			return err
 		}
 
-		defaultProfile := profs.Default()
-		profileData := profs.Data.Profiles
-		descriptions := profs.Data.Descriptions
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
 
-		fmt.Println("Available Claude API Profiles:")
-		fmt.Println()
+		effective, err := cmdutil.EffectiveSettings(settingsPath, profilesPath)
+		if err != nil {
+			return err
+		}
+		match := detectActiveProfile(profs, effective.Settings)
 
-		// Print header
-		fmt.Printf("┌%-20s┬%-30s┬%-40s┬%-20s┬%-10s┐\n",
-			strings.Repeat("─", 20), strings.Repeat("─", 30), strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 10))
-		fmt.Printf("│ %-18s │ %-28s │ %-38s │ %-18s │ %-8s │\n",
-			"Profile", "Description", "URL", "Model", "Status")
-		fmt.Printf("├%-20s┼%-30s┼%-40s┼%-20s┼%-10s┤\n",
-			strings.Repeat("─", 20), strings.Repeat("─", 30), strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 10))
+		result := listResult(profs, effective, match, revealSecrets(cmd))
+		slices.SortStableFunc(result.Profiles, sortBy)
+		if format.Structured() {
+			return output.Write(cmd.OutOrStdout(), format, result)
+		}
 
-		// Print rows
-		for name, config := range profileData {
-			status := ""
-			if name == defaultProfile {
-				status = "Default"
-			}
-			url := config["ANTHROPIC_BASE_URL"]
-			model := config["ANTHROPIC_MODEL"]
-			description := descriptions[name]
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, "Available Claude API Profiles:")
+		fmt.Fprintln(out)
 
-			// Truncate long values
-			if len(description) > 28 {
-				description = description[:25] + "..."
-			}
-			if len(url) > 38 {
-				url = url[:35] + "..."
-			}
-			if len(model) > 18 {
-				model = model[:15] + "..."
+		table := &output.Table{MaxWidth: output.TerminalWidth(out)}
+		for _, column := range columns {
+			table.Headers = append(table.Headers, column.Header)
+		}
+		for _, profile := range result.Profiles {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = column.Value(profile)
 			}
-
-			fmt.Printf("│ %-18s │ %-28s │ %-38s │ %-18s │ %-8s │\n",
-				name, description, url, model, status)
+			table.Rows = append(table.Rows, cells)
+		}
+		if err := table.Render(out); err != nil {
+			return err
 		}
 
-		// Print footer
-		fmt.Printf("└%-20s┴%-30s┴%-40s┴%-20s┴%-10s┘\n",
-			strings.Repeat("─", 20), strings.Repeat("─", 30), strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 10))
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Total profiles: %d\n", len(result.Profiles))
 
-		fmt.Println()
-		fmt.Printf("Total profiles: %d\n", len(profileData))
+		switch {
+		case match != nil && !match.Exact():
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Warning: %s has drifted from profile '%s':\n", effective.Path, match.Profile)
+			printDiffs(out, match.Diffs, "  ")
+		case result.Custom:
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Warning: %s does not match any profile\n", effective.Path)
+		}
+		if note := ambiguityNote(match); note != "" {
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Warning: %s matches profile '%s'. %s\n", effective.Path, match.Profile, note)
+		}
 
 		return nil
 	},
 }
+
+// selectListColumns returns the columns named by --columns, or the default
+// columns when none are named
+func selectListColumns(names []string) ([]listColumn, error) {
+	if len(names) == 0 {
+		names = defaultListColumns
+	}
+
+	var columns []listColumn
+	for _, name := range names {
+		i := slices.IndexFunc(listTableColumns, func(c listColumn) bool { return c.Name == strings.TrimSpace(name) })
+		if i < 0 {
+			valid := make([]string, len(listTableColumns))
+			for j, c := range listTableColumns {
+				valid[j] = c.Name
+			}
+			return nil, fmt.Errorf("unknown column '%s': expected %s", name, strings.Join(valid, ", "))
+		}
+		columns = append(columns, listTableColumns[i])
+	}
+	return columns, nil
+}
+
+// listResult summarizes the profiles, sorted by name, and which of them the
+// settings match
+func listResult(profs *profiles.Profiles, effective *cmdutil.ScopedSettings, match *profiles.Match, reveal bool) *output.ProfileList {
+	result := &output.ProfileList{
+		Profiles:     []output.ProfileSummary{},
+		SettingsPath: effective.Path,
+		Custom:       match == nil && len(effective.Settings.Env) > 0,
+	}
+	if profs.Has(profs.Default()) {
+		result.Default = profs.Default()
+	}
+	if match != nil {
+		result.Active = match.Profile
+		result.Drift = differences(match.Diffs, reveal)
+	}
+
+	lastUsed := lastUsedTimes(profs.Path)
+	for _, name := range slices.Sorted(maps.Keys(profs.Data.Profiles)) {
+		env := profs.Get(name)
+		summary := output.ProfileSummary{
+			Name:        name,
+			Description: profs.Data.Descriptions[name],
+			URL:         env["ANTHROPIC_BASE_URL"],
+			Model:       env["ANTHROPIC_MODEL"],
+			Default:     name == profs.Default(),
+			Active:      match != nil && match.Profile == name,
+			Drifted:     match != nil && match.Profile == name && !match.Exact(),
+		}
+		if t, ok := lastUsed[name]; ok {
+			summary.LastUsed = &t
+		}
+		result.Profiles = append(result.Profiles, summary)
+	}
+	return result
+}
+
+// lastUsedTimes returns when each profile was last switched to in any
+// settings file, according to the history
+func lastUsedTimes(profilesPath string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	entries, err := history.Load(history.Path(profilesPath))
+	if err != nil {
+		return times
+	}
+	for _, entry := range entries {
+		if entry.Profile != "" && entry.Time.After(times[entry.Profile]) {
+			times[entry.Profile] = entry.Time
+		}
+	}
+	return times
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listSort, "sort", "name", "Sort profiles by name, url, model or last-used")
+	listCmd.Flags().StringSliceVar(&listColumns, "columns", nil, "Table columns: profile, description, url, model, active, status, last-used")
+}
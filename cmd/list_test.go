@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +45,7 @@ func TestListCommand(t *testing.T) {
 
 	rootCmd := &cobra.Command{Use: "test"}
 	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", filepath.Join(tmpDir, "settings.json"), "settings path")
 	rootCmd.AddCommand(listCmd)
 
 	t.Run("list all profiles", func(t *testing.T) {
@@ -70,6 +76,7 @@ func TestProfilesCommandWithNoProfiles(t *testing.T) {
 
 	rootCmd := &cobra.Command{Use: "test"}
 	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", filepath.Join(tmpDir, "settings.json"), "settings path")
 	rootCmd.AddCommand(listCmd)
 
 	t.Run("list with no profiles", func(t *testing.T) {
@@ -113,3 +120,208 @@ func TestProfilesCommandAliases(t *testing.T) {
 		t.Error("list command should have 'profiles' alias")
 	}
 }
+
+func TestListCommandOutput(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.PersistentFlags().String("output", "table", "output format")
+	rootCmd.AddCommand(listCmd, useCmd)
+
+	rootCmd.SetArgs([]string{"use", "another-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+
+	t.Run("json", func(t *testing.T) {
+		out.Reset()
+		rootCmd.SetArgs([]string{"list", "--output", "json", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+
+		var result output.ProfileList
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+		}
+		if len(result.Profiles) != 2 || result.Profiles[0].Name != "another-profile" || result.Profiles[1].Name != "test-profile" {
+			t.Errorf("profiles = %+v, want both profiles sorted by name", result.Profiles)
+		}
+		if result.Default != "test-profile" || result.Active != "another-profile" || !result.Profiles[0].Active {
+			t.Errorf("result = %+v, want test-profile default and another-profile active", result)
+		}
+		if result.Profiles[1].URL != "https://api.test.com" || result.Profiles[1].Model != "test-model" {
+			t.Errorf("test-profile = %+v", result.Profiles[1])
+		}
+	})
+
+	t.Run("template", func(t *testing.T) {
+		out.Reset()
+		rootCmd.SetArgs([]string{"list", "--output", "template={{range .Profiles}}{{.Name}}{{\"\\n\"}}{{end}}", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+		if out.String() != "another-profile\ntest-profile\n" {
+			t.Errorf("output = %q", out.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"list", "--output", "xml", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("expected error for an unknown output format")
+		}
+	})
+}
+
+func TestListCommandSortAndColumns(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.Add("zhipu", map[string]string{"ANTHROPIC_BASE_URL": "https://a.example.com", "ANTHROPIC_MODEL": "glm-4.6"}, "智谱清言 GLM coding plan for long descriptions"); err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.PersistentFlags().String("output", "table", "output format")
+	rootCmd.AddCommand(listCmd, useCmd)
+	defer func() {
+		listSort = "name"
+		listColumns = nil
+	}()
+
+	for _, name := range []string{"test-profile", "zhipu"} {
+		rootCmd.SetArgs([]string{"use", name, "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+
+	names := func(t *testing.T, args ...string) string {
+		t.Helper()
+		out.Reset()
+		rootCmd.SetArgs(append([]string{"list", "-p", profilesPath, "-s", settingsPath, "--output", "template={{range .Profiles}}{{.Name}} {{end}}"}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"name", "another-profile test-profile zhipu"},
+		{"url", "another-profile zhipu test-profile"},
+		{"model", "another-profile zhipu test-profile"},
+		{"last-used", "zhipu test-profile another-profile"},
+	}
+	for _, tt := range tests {
+		if got := names(t, "--sort", tt.sort); got != tt.want {
+			t.Errorf("--sort %s = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+
+	t.Run("columns", func(t *testing.T) {
+		t.Setenv("COLUMNS", "")
+		out.Reset()
+		rootCmd.SetArgs([]string{"list", "-p", profilesPath, "-s", settingsPath, "--output", "table", "--sort", "name", "--columns", "profile,description"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+		got := out.String()
+		if !strings.Contains(got, "│ Profile ") || strings.Contains(got, "URL") {
+			t.Errorf("table does not have only the chosen columns:\n%s", got)
+		}
+		if !strings.Contains(got, "智谱清言 GLM coding plan for long descriptions") {
+			t.Errorf("description was cut without a width limit:\n%s", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"list", "-p", profilesPath, "-s", settingsPath, "--sort", "size"})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("expected error for an unknown sort")
+		}
+		rootCmd.SetArgs([]string{"list", "-p", profilesPath, "-s", settingsPath, "--sort", "name", "--columns", "token"})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("expected error for an unknown column")
+		}
+	})
+}
+
+func TestListCommandResolvesProfiles(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.Add("child", map[string]string{"API_TIMEOUT_MS": "600000"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Extends = map[string]profiles.StringList{"child": {"test-profile"}}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.PersistentFlags().String("output", "table", "output format")
+	rootCmd.AddCommand(listCmd, useCmd)
+	defer func() { listSort = "name" }()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"list", "-p", profilesPath, "-s", settingsPath, "--sort", "url", "--output", "template={{range .Profiles}}{{.Name}}={{.URL}},{{.Model}} {{end}}"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("list command failed: %v", err)
+	}
+	if got, want := strings.TrimSpace(out.String()), "another-profile=,another-model child=https://api.test.com,test-model test-profile=https://api.test.com,test-model"; got != want {
+		t.Errorf("list = %q, want %q", got, want)
+	}
+
+	rootCmd.SetArgs([]string{"use", "child", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	s, err := settings.New(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Env["API_TIMEOUT_MS"] = "1000"
+	if err := s.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	rootCmd.SetArgs([]string{"list", "-p", profilesPath, "-s", settingsPath, "--sort", "name", "--output", "table"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("list command failed: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "has drifted from profile 'child'") || !strings.Contains(got, "~ API_TIMEOUT_MS: 1000") {
+		t.Errorf("drift warning was not written to the command output:\n%s", got)
+	}
+}
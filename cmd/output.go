@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+// resultFormat returns the format selected with --output; commands run
+// without the global flags print tables
+func resultFormat(cmd *cobra.Command) (*output.Format, error) {
+	if f := cmd.Flag("output"); f != nil {
+		return output.ParseFormat(f.Value.String())
+	}
+	return output.ParseFormat(output.FormatTable)
+}
+
+// revealSecrets reports whether --reveal was passed
+func revealSecrets(cmd *cobra.Command) bool {
+	f := cmd.Flag("reveal")
+	return f != nil && f.Value.String() == "true"
+}
+
+// displayValue masks a sensitive value unless reveal is set
+func displayValue(key, value string, reveal bool) string {
+	if reveal {
+		return value
+	}
+	return maskDiffValue(key, value)
+}
+
+// displayEnv returns env with sensitive values masked unless reveal is set
+func displayEnv(env map[string]string, reveal bool) map[string]string {
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		result[k] = displayValue(k, v, reveal)
+	}
+	return result
+}
+
+// differences converts the drift of a match into result differences
+func differences(diffs []profiles.Diff, reveal bool) []output.Difference {
+	var result []output.Difference
+	for _, d := range diffs {
+		result = append(result, output.Difference{
+			Key:      d.Key,
+			Profile:  displayValue(d.Key, d.Want, reveal),
+			Settings: displayValue(d.Key, d.Got, reveal),
+		})
+	}
+	return result
+}
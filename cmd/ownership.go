@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/huangdijia/ccswitch/internal/ownership"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
+)
+
+// appliedRecord returns what ccswitch last wrote to s. Settings written
+// before ownership was tracked are attributed to the profile they match, when
+// profs is given; keys the profile does not define are left to the user
+func appliedRecord(profilesPath string, profs *profiles.Profiles, s *settings.ClaudeSettings) *ownership.Record {
+	record, err := ownership.Get(ownership.Path(profilesPath), s.Path)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if record != nil || profs == nil {
+		return record
+	}
+
+	match := detectActiveProfile(profs, s)
+	if match == nil {
+		return nil
+	}
+	resolved, err := profs.Resolve(match.Profile)
+	if err != nil {
+		return nil
+	}
+	record = &ownership.Record{Profile: match.Profile, Settings: resolved.Settings}
+	for _, k := range slices.Sorted(maps.Keys(resolved.Env)) {
+		if _, ok := s.Env[k]; ok {
+			record.Env = append(record.Env, k)
+		}
+	}
+	if model := resolved.Env["ANTHROPIC_MODEL"]; model == s.Model {
+		record.Model = model
+	}
+	return record
+}
+
+// removeOwned removes the env keys, model and settings that record says
+// ccswitch wrote to s. Merged settings are only removed while unchanged, and
+// the user's env and settings values they replaced are restored
+func removeOwned(s *settings.ClaudeSettings, record *ownership.Record) {
+	if record == nil {
+		return
+	}
+	for _, k := range record.Env {
+		delete(s.Env, k)
+	}
+	for k, v := range record.ReplacedEnv {
+		s.Env[k] = v
+	}
+	if record.Model != "" && s.Model == record.Model {
+		s.Model = ""
+	}
+	s.Unmerge(record.Settings, record.Replaced)
+}
+
+// recordOwnership stores what ccswitch wrote to settingsPath; a nil record
+// clears it. Failures only warn because the settings have already been written
+func recordOwnership(profilesPath, settingsPath string, record *ownership.Record) {
+	if err := ownership.Set(ownership.Path(profilesPath), settingsPath, record); err != nil {
+		fmt.Printf("Warning: failed to record owned settings: %v\n", err)
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/proxy"
+	"github.com/huangdijia/ccswitch/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var (
+	proxyListen  string
+	proxyProfile string
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local proxy that forwards to the active profile",
+	Long: `Run a local Anthropic-compatible proxy and point Claude Code at it.
+
+Requests, including streamed responses, are forwarded to the upstream of the
+active profile with that profile's credentials, and model names are mapped to
+the profile's models by family (opus, sonnet, haiku). While the proxy runs,
+"ccswitch use <profile>" redirects new requests to the new profile without
+restarting Claude Code. Stopping the proxy writes the active profile back into
+the settings file.
+
+The proxy only listens on loopback addresses and only accepts requests that
+carry the random token it writes into the settings file for this run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+		settingsPath := cmd.Flag("settings").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+		settingsPath = cmdutil.ResolveSettingsPath(settingsPath, profilesPath)
+		statePath := proxy.StatePath(profs.Path)
+
+		if state, err := proxy.Running(statePath); err != nil {
+			return err
+		} else if state != nil {
+			return fmt.Errorf("a proxy is already running on %s", state.Address)
+		}
+
+		// The proxy spends the profile's credentials for anyone who can
+		// reach it, so it only listens on the local machine
+		host, _, err := net.SplitHostPort(proxyListen)
+		if err != nil {
+			return fmt.Errorf("invalid listen address '%s': %w", proxyListen, err)
+		}
+		if !proxy.IsLoopback(host) {
+			return fmt.Errorf("refusing to listen on %s: the proxy only listens on loopback addresses such as 127.0.0.1", proxyListen)
+		}
+
+		profileName, err := proxyInitialProfile(profs, settingsPath, statePath)
+		if err != nil {
+			return err
+		}
+		token, err := proxy.NewToken()
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", proxyListen)
+		if err != nil {
+			return err
+		}
+
+		// A state file left behind by a crashed run keeps its mode when
+		// replaced, so it is removed rather than overwritten
+		os.Remove(statePath)
+		state := &proxy.State{Address: listener.Addr().String(), Profile: profileName, PID: os.Getpid(), Token: token}
+		if err := state.Save(statePath); err != nil {
+			listener.Close()
+			return err
+		}
+		defer os.Remove(statePath)
+
+		// Resolve the initial profile before serving so a vault passphrase
+		// prompt does not interleave with request logs
+		upstream := proxyUpstream(profs.Path, statePath)
+		if _, err := upstream(); err != nil {
+			listener.Close()
+			return err
+		}
+
+		opts := applyOptions{scope: settings.ScopeUser, settingsPath: settingsPath, profilesPath: profilesPath}
+		if err := applyProfile(profs, profileName, opts); err != nil {
+			listener.Close()
+			return err
+		}
+
+		server := &http.Server{Handler: &proxy.Server{
+			Upstream: upstream,
+			Token:    token,
+			Logger:   log.New(cmd.OutOrStdout(), "", log.LstdFlags),
+		}}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(context.Background())
+		}()
+
+		fmt.Printf("Proxy listening on %s (press Ctrl+C to stop)\n", state.URL())
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
+		// Write the last active profile back so clients talk to it directly
+		if last, err := proxy.LoadState(statePath); err == nil && last != nil {
+			profileName = last.Profile
+		}
+		os.Remove(statePath)
+		if profs, err = cmdutil.LoadProfiles(profilesPath); err != nil {
+			return err
+		}
+		fmt.Printf("Proxy stopped, restoring profile %s\n", profileName)
+		return applyProfile(profs, profileName, opts)
+	},
+}
+
+// proxyInitialProfile picks the profile the proxy starts with: the --profile
+// flag, the profile matching the settings file, the profile of a previous
+// proxy run, or the default profile
+func proxyInitialProfile(profs *profiles.Profiles, settingsPath, statePath string) (string, error) {
+	name := proxyProfile
+	if name == "" {
+		currentSettings, err := cmdutil.LoadSettings(settingsPath)
+		if err != nil {
+			return "", err
+		}
+		name = activeProfileName(profs, currentSettings)
+	}
+	if name == "" {
+		if state, err := proxy.LoadState(statePath); err == nil && state != nil {
+			name = state.Profile
+		}
+	}
+	if name == "" {
+		name = profs.Data.Default
+	}
+	if name == "" {
+		return "", fmt.Errorf("cannot determine the profile to proxy (use --profile)")
+	}
+	if err := cmdutil.ValidateProfile(profs, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// proxyUpstream returns an UpstreamFunc that re-reads the proxy state and the
+// profiles on every request, so switches and edits apply immediately
+func proxyUpstream(profilesPath, statePath string) proxy.UpstreamFunc {
+	var (
+		mu       sync.Mutex
+		resolver *cmdutil.SecretResolver
+	)
+
+	return func() (*proxy.Upstream, error) {
+		state, err := proxy.LoadState(statePath)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil || state.Profile == "" {
+			return nil, fmt.Errorf("no active profile")
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return nil, err
+		}
+		resolved, err := profs.Resolve(state.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if resolver == nil {
+			resolver = cmdutil.NewSecretResolver(profs)
+		}
+		env := resolved.Env
+		if err := applyToken(context.Background(), profs, state.Profile, resolved.TokenCommand, env); err != nil {
+			return nil, err
+		}
+		env, err = resolver.Resolve(env)
+		if err != nil {
+			return nil, err
+		}
+		return &proxy.Upstream{Name: state.Profile, Env: env, ModelMap: resolved.ModelMap}, nil
+	}
+}
+
+func init() {
+	proxyCmd.Flags().StringVarP(&proxyListen, "listen", "l", proxy.DefaultAddress, "Address to listen on")
+	proxyCmd.Flags().StringVar(&proxyProfile, "profile", "", "Profile to start with (defaults to the active profile)")
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+func TestUseWhileProxyRunning(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	statePath := proxy.StatePath(profilesPath)
+	state := &proxy.State{Address: listener.Addr().String(), Profile: "another-profile"}
+	if err := state.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+	upstream := proxyUpstream(profilesPath, statePath)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+	useScope = ""
+	useFallback = false
+
+	rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+
+	s, err := cmdutil.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Env["ANTHROPIC_BASE_URL"]; got != state.URL() {
+		t.Errorf("ANTHROPIC_BASE_URL = %v, want %v", got, state.URL())
+	}
+	if _, ok := s.Env["ANTHROPIC_MODEL"]; ok {
+		t.Error("settings should not pin a model while proxied")
+	}
+
+	active, err := upstream()
+	if err != nil {
+		t.Fatalf("upstream() error = %v", err)
+	}
+	if active.Name != "test-profile" || active.Env["ANTHROPIC_BASE_URL"] != "https://api.test.com" {
+		t.Errorf("upstream() = %v, want test-profile", active)
+	}
+
+	// Without a listening proxy the profile is written directly again
+	listener.Close()
+	rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	s, _ = cmdutil.LoadSettings(settingsPath)
+	if got := s.Env["ANTHROPIC_BASE_URL"]; got != "https://api.test.com" {
+		t.Errorf("ANTHROPIC_BASE_URL = %v, want direct upstream", got)
+	}
+	os.Remove(statePath)
+}
+
+func TestProxyRefusesNonLoopbackListen(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(proxyCmd)
+	defer func() { proxyListen = proxy.DefaultAddress }()
+
+	rootCmd.SetArgs([]string{"proxy", "--listen", "0.0.0.0:0", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error when listening on all interfaces, got nil")
+	}
+	if _, err := os.Stat(proxy.StatePath(profilesPath)); !os.IsNotExist(err) {
+		t.Errorf("proxy state written for a refused address: %v", err)
+	}
+}
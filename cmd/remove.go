@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/huangdijia/ccswitch/internal/termui"
+	"github.com/spf13/cobra"
+)
+
+var removeDefault string
+
+var removeCmd = &cobra.Command{
+	Use:     "remove [profile...]",
+	Aliases: []string{"rm"},
+	Short:   "Remove one or more profiles",
+	Long: `Remove profiles together with their descriptions, other metadata and stored
+secrets. Without arguments, the profiles to remove are chosen interactively.
+
+The default profile can only be removed when another default is chosen with
+--default. Profiles that other profiles extend cannot be removed, unless
+those are removed too.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		profileNames := args
+		if len(profileNames) == 0 {
+			available := profs.GetAll()
+			if len(available) == 0 {
+				return fmt.Errorf("no profiles available")
+			}
+			sort.Strings(available)
+
+			profileNames, err = selectItems(cmd, termui.SelectConfig{
+				Prompt: "Select profiles to remove:",
+				Items:  available,
+				Details: func(name string) string {
+					return profileDetails(profs.Data.Descriptions[name], profs.Get(name))
+				},
+			})
+			if err != nil {
+				if err == termui.ErrCanceled {
+					return nil
+				}
+				if err == termui.ErrNoInput {
+					return fmt.Errorf("no profile specified (use 'ccswitch remove <profile>')")
+				}
+				return err
+			}
+		}
+
+		if removeDefault != "" && slices.Contains(profileNames, removeDefault) {
+			return fmt.Errorf("the new default must be a different profile")
+		}
+
+		// The profiles are loaded again under the lock, in case another
+		// command changed them while the user was choosing
+		owned := make(map[string][]string)
+		var store secrets.Store
+		err = cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			for _, profileName := range profileNames {
+				if err := cmdutil.RequireProfile(profs, profileName); err != nil {
+					return err
+				}
+			}
+
+			if removeDefault != "" {
+				if err := profs.SetDefault(removeDefault); err != nil {
+					return err
+				}
+			}
+
+			var ownedKeys []string
+			for _, profileName := range profileNames {
+				owned[profileName] = profs.OwnSecrets(profileName)
+				ownedKeys = append(ownedKeys, owned[profileName]...)
+			}
+			var err error
+			if store, err = cmdutil.OpenSecretStoreFor(profs, ownedKeys); err != nil {
+				return err
+			}
+
+			return removeProfiles(profs, profileNames)
+		})
+		if err != nil {
+			return err
+		}
+		for _, profileName := range profileNames {
+			if err := cmdutil.DeleteSecrets(store, profileName, owned[profileName]); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+
+		for _, profileName := range profileNames {
+			output.Success("Profile '%s' removed", profileName)
+		}
+		if removeDefault != "" {
+			output.Success("Default profile set to '%s'", removeDefault)
+		}
+		return nil
+	},
+}
+
+// removeProfiles removes the named profiles, removing profiles that extend
+// others before their parents. Nothing is saved, so on error the caller can
+// drop every change
+func removeProfiles(profs *profiles.Profiles, names []string) error {
+	pending := names
+	for len(pending) > 0 {
+		var retry []string
+		var firstErr error
+		for _, name := range pending {
+			if err := profs.Remove(name); err != nil {
+				retry = append(retry, name)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		if len(retry) == len(pending) {
+			return firstErr
+		}
+		pending = retry
+	}
+	return nil
+}
+
+func init() {
+	removeCmd.Flags().StringVar(&removeDefault, "default", "", "New default profile when removing the current default")
+}
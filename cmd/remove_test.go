@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+func TestRemoveCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(removeCmd)
+
+	t.Run("refuses the default profile", func(t *testing.T) {
+		removeDefault = ""
+		rootCmd.SetArgs([]string{"remove", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error when removing the default profile, got nil")
+		}
+	})
+
+	t.Run("removes the default with a new default", func(t *testing.T) {
+		removeDefault = ""
+		rootCmd.SetArgs([]string{"remove", "test-profile", "--default", "another-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("remove command failed: %v", err)
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profs.Has("test-profile") {
+			t.Error("test-profile was not removed")
+		}
+		if profs.Data.Default != "another-profile" {
+			t.Errorf("Default = %v, want another-profile", profs.Data.Default)
+		}
+	})
+
+	removeDefault = ""
+}
+
+func TestRemoveCommandSeveralProfiles(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base", "child", "other"} {
+		if err := profs.Add(name, map[string]string{"ANTHROPIC_MODEL": name}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	profs.Data.Extends = map[string]profiles.StringList{"child": {"base"}}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().Bool("no-tui", false, "numbered menu")
+	rootCmd.AddCommand(removeCmd)
+	removeDefault = ""
+	defer rootCmd.SetIn(nil)
+
+	t.Run("parent before child", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"remove", "base", "child", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("remove command failed: %v", err)
+		}
+		profs, _ := cmdutil.LoadProfiles(profilesPath)
+		if profs.Has("base") || profs.Has("child") {
+			t.Error("base and child were not removed")
+		}
+	})
+
+	t.Run("nothing is removed on error", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"remove", "other", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Fatal("Expected error when removing the default profile, got nil")
+		}
+		profs, _ := cmdutil.LoadProfiles(profilesPath)
+		if !profs.Has("other") {
+			t.Error("other was removed although the command failed")
+		}
+	})
+
+	t.Run("chosen from the menu", func(t *testing.T) {
+		// Items are sorted: another-profile, other, test-profile
+		rootCmd.SetIn(strings.NewReader("1, other\n"))
+		rootCmd.SetArgs([]string{"remove", "--no-tui", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("remove command failed: %v", err)
+		}
+		profs, _ := cmdutil.LoadProfiles(profilesPath)
+		if profs.Has("another-profile") || profs.Has("other") || !profs.Has("test-profile") {
+			t.Errorf("profiles after remove = %v", profs.GetAll())
+		}
+	})
+}
+
+func TestRemoveCommandBrokenProfile(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Extends = map[string]profiles.StringList{"another-profile": {"missing"}}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(removeCmd)
+	removeDefault = ""
+
+	rootCmd.SetArgs([]string{"remove", "another-profile", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remove of a broken profile failed: %v", err)
+	}
+	profs, _ = cmdutil.LoadProfiles(profilesPath)
+	if profs.Has("another-profile") {
+		t.Error("another-profile was not removed")
+	}
+}
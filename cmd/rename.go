@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/ownership"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:     "rename <profile> <new-name>",
+	Aliases: []string{"mv"},
+	Short:   "Rename a profile",
+	Long:    "Rename a profile, updating the default profile, every profile that extends it or falls back to it, its stored secrets and the switch history.",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		// Secrets are stored under the new name before ccs.json refers to
+		// it, and the old ones deleted only once nothing refers to them
+		var keys []string
+		var store secrets.Store
+		err := cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			if err := cmdutil.RequireProfile(profs, args[0]); err != nil {
+				return err
+			}
+			keys = profs.OwnSecrets(args[0])
+			var err error
+			if store, err = cmdutil.OpenSecretStoreFor(profs, keys); err != nil {
+				return err
+			}
+			if err := profs.Rename(args[0], args[1]); err != nil {
+				return err
+			}
+			return cmdutil.CopySecrets(store, args[0], args[1], keys)
+		})
+		if err != nil {
+			return err
+		}
+		if err := cmdutil.DeleteSecrets(store, args[0], keys); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		// Keep 'use -' and 'reset' following the profile
+		if err := history.RenameProfile(history.Path(profilesPath), args[0], args[1]); err != nil {
+			fmt.Printf("Warning: failed to update history: %v\n", err)
+		}
+		if err := ownership.RenameProfile(ownership.Path(profilesPath), args[0], args[1]); err != nil {
+			fmt.Printf("Warning: failed to update ownership records: %v\n", err)
+		}
+
+		output.Success("Profile '%s' renamed to '%s'", args[0], args[1])
+		return nil
+	},
+}
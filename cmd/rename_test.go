@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/history"
+	"github.com/huangdijia/ccswitch/internal/ownership"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+func TestRenameCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(renameCmd)
+
+	rootCmd.SetArgs([]string{"rename", "test-profile", "renamed", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("rename command failed: %v", err)
+	}
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profs.Has("test-profile") || !profs.Has("renamed") {
+		t.Error("profile was not renamed")
+	}
+	if profs.Data.Default != "renamed" {
+		t.Errorf("Default = %v, want renamed", profs.Data.Default)
+	}
+
+	rootCmd.SetArgs([]string{"rename", "renamed", "another-profile", "-p", profilesPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error when renaming onto an existing profile, got nil")
+	}
+}
+
+func TestRenameCommandBrokenProfileAndState(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Extends = map[string]profiles.StringList{"another-profile": {"missing"}}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Append(history.Path(profilesPath), history.Entry{Profile: "another-profile", SettingsPath: settingsPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ownership.Set(ownership.Path(profilesPath), settingsPath, &ownership.Record{Profile: "another-profile"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(renameCmd)
+
+	rootCmd.SetArgs([]string{"rename", "another-profile", "renamed", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("rename of a broken profile failed: %v", err)
+	}
+
+	entries, err := history.Load(history.Path(profilesPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Profile != "renamed" {
+		t.Errorf("history = %v, want one entry for renamed", entries)
+	}
+	record, err := ownership.Get(ownership.Path(profilesPath), settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Profile != "renamed" {
+		t.Errorf("ownership record = %v, want profile renamed", record)
+	}
+}
@@ -1,16 +1,28 @@
 package cmd
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/huangdijia/ccswitch/internal/cmdutil"
 	"github.com/huangdijia/ccswitch/internal/output"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
 	"github.com/spf13/cobra"
 )
 
+var resetAll bool
+
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Reset Claude settings to default state",
-	Long:  "This command resets your Claude settings to their default state",
+	Long: `This command resets your Claude settings to their default state.
+
+Only the env keys, model and settings written by ccswitch are removed; keys
+you set yourself are kept. Use --all to clear the whole env and model.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		settingsPath := cmd.Flag("settings").Value.String()
 		profilesPath := cmd.Flag("profiles").Value.String()
@@ -24,22 +36,49 @@ var resetCmd = &cobra.Command{
 			settingsPath = pathutil.DefaultSettingsPath()
 		}
 
+		if expanded, err := pathutil.ExpandHome(profilesPath); err == nil {
+			profilesPath = expanded
+		}
+
+		unlock, err := cmdutil.LockSettings(settingsPath)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
 		currentSettings, err := cmdutil.LoadSettings(settingsPath)
 		if err != nil {
 			return err
 		}
 
-		// Reset settings to empty state
-		currentSettings.Env = make(map[string]interface{})
-		currentSettings.Model = ""
+		profs, err := profiles.New(profilesPath)
+		if err != nil {
+			profs = nil
+		}
+		removeOwned(currentSettings, appliedRecord(profilesPath, profs, currentSettings))
+		if resetAll {
+			currentSettings.Env = make(map[string]interface{})
+			currentSettings.Model = ""
+		}
 
 		// Write the reset settings
 		if err := currentSettings.Write(); err != nil {
 			return err
 		}
 
+		recordHistory(profilesPath, "", currentSettings.Path, settings.ScopeUser)
+		recordOwnership(profilesPath, currentSettings.Path, nil)
+
 		output.Success("Settings have been reset to default")
+		if len(currentSettings.Env) > 0 {
+			preserved := slices.Sorted(maps.Keys(currentSettings.Env))
+			fmt.Printf("  Preserved: %s (use --all to remove)\n", strings.Join(preserved, ", "))
+		}
 
 		return nil
 	},
 }
+
+func init() {
+	resetCmd.Flags().BoolVar(&resetAll, "all", false, "Also remove env keys and the model not set by ccswitch")
+}
@@ -31,8 +31,10 @@ func TestResetCommand(t *testing.T) {
 	rootCmd.PersistentFlags().StringP("profiles", "p", "", "profiles path")
 	rootCmd.AddCommand(resetCmd)
 
+	t.Cleanup(func() { resetAll = false })
+
 	t.Run("reset settings", func(t *testing.T) {
-		rootCmd.SetArgs([]string{"reset", "-s", settingsPath})
+		rootCmd.SetArgs([]string{"reset", "--all", "-s", settingsPath})
 		err := rootCmd.Execute()
 		if err != nil {
 			t.Errorf("reset command failed: %v", err)
@@ -49,7 +51,7 @@ func TestResetCommand(t *testing.T) {
 			t.Fatalf("Failed to parse settings: %v", err)
 		}
 
-		// Settings should be empty after reset
+		// Settings should be empty after reset --all
 		if len(settings) != 0 {
 			t.Errorf("Settings should be empty after reset, got: %v", settings)
 		}
@@ -111,3 +113,41 @@ func TestResetCommandWithProfilesPath(t *testing.T) {
 		// The settings file location is determined from profiles config
 	})
 }
+
+func TestResetCommandKeepsUserKeys(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"DISABLE_TELEMETRY": "1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd, resetCmd)
+	resetAll = false
+
+	rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"reset", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("reset command failed: %v", err)
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	env, _ := settings["env"].(map[string]any)
+	if len(env) != 1 || env["DISABLE_TELEMETRY"] != "1" {
+		t.Errorf("env after reset = %v, want only DISABLE_TELEMETRY", env)
+	}
+	if _, ok := settings["model"]; ok {
+		t.Errorf("model was not removed: %v", settings["model"])
+	}
+}
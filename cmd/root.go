@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/output"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +14,12 @@ import (
 var (
 	profilesPath string
 	settingsPath string
+	// outputFormat is the --output format of read commands
+	outputFormat string
+	// reveal prints secrets unmasked in the output of read commands
+	reveal bool
+	// noTUI replaces the interactive selector by a numbered menu
+	noTUI bool
 	// appVersion holds the version of the application
 	appVersion string
 	// appCommit holds the git commit hash
@@ -26,11 +35,24 @@ var rootCmd = &cobra.Command{
 and switch between them. This is useful when you need to use different API endpoints,
 models, or authentication tokens for different projects or environments.`,
 	Version: appVersion,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Keep backups of replaced files next to the profiles file
+		dir, err := backupDir(cmd.Flag("profiles").Value.String())
+		if err != nil {
+			return err
+		}
+		atomicfile.BackupDir = dir
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -42,6 +64,9 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&profilesPath, "profiles", "p", defaultProfilesPath, "Path to the profiles configuration file")
 	rootCmd.PersistentFlags().StringVarP(&settingsPath, "settings", "s", "", "Path to the Claude settings file")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", output.FormatTable, "Output format of list, show and diff: table, json, yaml or template=<Go template>")
+	rootCmd.PersistentFlags().BoolVar(&reveal, "reveal", false, "Print tokens and keys unmasked")
+	rootCmd.PersistentFlags().BoolVar(&noTUI, "no-tui", false, "Pick from a numbered menu instead of the interactive selector")
 
 	// Add subcommands
 	rootCmd.AddCommand(initCmd)
@@ -51,6 +76,26 @@ func init() {
 	rootCmd.AddCommand(useCmd)
 	rootCmd.AddCommand(resetCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(shellInitCmd)
+	rootCmd.AddCommand(autoCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(failoverCmd)
+	rootCmd.AddCommand(proxyCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(setDefaultCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(unsetCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(tokenCmd)
 }
 
 // SetVersion sets the application version, commit and build date
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsBackend string
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage how authentication tokens are stored",
+	Long: `Manage how authentication tokens are stored.
+
+When a secrets backend is configured, ccs.json only holds references such as
+secret://glm/ANTHROPIC_AUTH_TOKEN and the tokens themselves live in the backend:
+  vault           a passphrase-encrypted file next to ccs.json (vault.json);
+                  the passphrase is read from CCSWITCH_VAULT_PASSPHRASE or prompted
+  secret-service  the desktop keyring via secret-tool (GNOME Keyring, KWallet)`,
+}
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move plaintext tokens from ccs.json into a secrets backend",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		unlock, err := cmdutil.LockProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		backend := secretsBackend
+		if backend == "" {
+			backend = profs.Data.SecretsBackend
+		}
+		if backend == "" {
+			backend = secrets.BackendVault
+		}
+		if profs.Data.SecretsBackend != "" && profs.Data.SecretsBackend != backend {
+			return fmt.Errorf("profiles already use the '%s' backend", profs.Data.SecretsBackend)
+		}
+
+		store, err := cmdutil.OpenSecretBackend(backend, profilesPath)
+		if err != nil {
+			return err
+		}
+		profs.Data.SecretsBackend = backend
+
+		names := profs.GetAll()
+		sort.Strings(names)
+
+		migrated := 0
+		for _, name := range names {
+			n, err := cmdutil.MoveSecrets(store, name, profs.Data.Profiles[name])
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				fmt.Printf("  %s: %d token(s) moved\n", name, n)
+				migrated += n
+			}
+		}
+
+		// Backups of ccs.json hold the plaintext tokens, so none is made and
+		// the existing ones are removed
+		if err := profs.SaveNoBackup(); err != nil {
+			return err
+		}
+		removed, err := atomicfile.RemoveBackups(profs.Path)
+		if err != nil {
+			return fmt.Errorf("failed to remove backups of %s: %w", profs.Path, err)
+		}
+
+		output.Success("Migrated %d token(s) to the %s backend", migrated, backend)
+		if removed > 0 {
+			fmt.Printf("  Removed %d backup(s) of %s holding plaintext tokens\n", removed, profs.Path)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	secretsMigrateCmd.Flags().StringVarP(&secretsBackend, "backend", "b", "", "Secrets backend: vault or secret-service (default vault)")
+	secretsCmd.AddCommand(secretsMigrateCmd)
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+func TestSecretsMigrateAndUse(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+	t.Setenv(cmdutil.VaultPassphraseEnv, "test-passphrase")
+
+	profilesConfig := map[string]any{
+		"settingsPath": settingsPath,
+		"profiles": map[string]any{
+			"glm": map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://api.test.com",
+				"ANTHROPIC_AUTH_TOKEN": "sk-glm-secret-token",
+			},
+			"placeholder": map[string]string{
+				"ANTHROPIC_AUTH_TOKEN": "sk-",
+			},
+		},
+	}
+	profilesData, _ := json.MarshalIndent(profilesConfig, "", "    ")
+	if err := os.WriteFile(profilesPath, profilesData, 0644); err != nil {
+		t.Fatalf("Failed to write profiles config: %v", err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(copyCmd, renameCmd, removeCmd)
+
+	t.Run("migrate moves tokens into the vault", func(t *testing.T) {
+		atomicfile.BackupDir = filepath.Join(tmpDir, "backups")
+		defer func() { atomicfile.BackupDir = "" }()
+		// Make a backup holding the plaintext token
+		saved, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved.Data.Descriptions = map[string]string{"glm": "GLM"}
+		if err := saved.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		rootCmd.SetArgs([]string{"secrets", "migrate", "--backend", "vault", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("secrets migrate failed: %v", err)
+		}
+
+		data, err := os.ReadFile(profilesPath)
+		if err != nil {
+			t.Fatalf("Failed to read profiles: %v", err)
+		}
+		if strings.Contains(string(data), "sk-glm-secret-token") {
+			t.Error("profiles file still contains the plaintext token")
+		}
+		backups, err := atomicfile.ListBackups(atomicfile.BackupDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range backups {
+			if strings.Contains(string(b.Data), "sk-glm-secret-token") {
+				t.Errorf("backup %s still contains the plaintext token", b.ID)
+			}
+		}
+
+		var config map[string]any
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatalf("Failed to parse profiles: %v", err)
+		}
+		if config["secretsBackend"] != "vault" {
+			t.Errorf("secretsBackend = %v, want %v", config["secretsBackend"], "vault")
+		}
+		profs := config["profiles"].(map[string]any)
+		glm := profs["glm"].(map[string]any)
+		if glm["ANTHROPIC_AUTH_TOKEN"] != "secret://glm/ANTHROPIC_AUTH_TOKEN" {
+			t.Errorf("ANTHROPIC_AUTH_TOKEN = %v, want a secret reference", glm["ANTHROPIC_AUTH_TOKEN"])
+		}
+		placeholder := profs["placeholder"].(map[string]any)
+		if placeholder["ANTHROPIC_AUTH_TOKEN"] != "sk-" {
+			t.Errorf("placeholder token = %v, want it left untouched", placeholder["ANTHROPIC_AUTH_TOKEN"])
+		}
+	})
+
+	t.Run("use resolves references", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "glm", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatalf("Failed to read settings: %v", err)
+		}
+		var settings map[string]any
+		if err := json.Unmarshal(data, &settings); err != nil {
+			t.Fatalf("Failed to parse settings: %v", err)
+		}
+		env := settings["env"].(map[string]any)
+		if env["ANTHROPIC_AUTH_TOKEN"] != "sk-glm-secret-token" {
+			t.Errorf("ANTHROPIC_AUTH_TOKEN = %v, want %v", env["ANTHROPIC_AUTH_TOKEN"], "sk-glm-secret-token")
+		}
+	})
+
+	t.Run("wrong passphrase fails", func(t *testing.T) {
+		t.Setenv(cmdutil.VaultPassphraseEnv, "wrong")
+		rootCmd.SetArgs([]string{"use", "glm", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error with wrong passphrase, got nil")
+		}
+	})
+
+	t.Run("copy, rename and remove carry stored secrets", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"copy", "glm", "glm-2"},
+			{"rename", "glm", "zhipu"},
+			{"remove", "glm-2"},
+		} {
+			rootCmd.SetArgs(append(args, "-p", profilesPath))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("%s command failed: %v", args[0], err)
+			}
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := profs.Get("zhipu")["ANTHROPIC_AUTH_TOKEN"]; got != "secret://zhipu/ANTHROPIC_AUTH_TOKEN" {
+			t.Errorf("renamed ANTHROPIC_AUTH_TOKEN = %v, want %v", got, "secret://zhipu/ANTHROPIC_AUTH_TOKEN")
+		}
+
+		vault, err := secrets.OpenVault(cmdutil.VaultPath(profilesPath), "test-passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := vault.Get("zhipu/ANTHROPIC_AUTH_TOKEN"); err != nil || got != "sk-glm-secret-token" {
+			t.Errorf("renamed secret = %q, %v, want %q", got, err, "sk-glm-secret-token")
+		}
+		for _, name := range []string{"glm/ANTHROPIC_AUTH_TOKEN", "glm-2/ANTHROPIC_AUTH_TOKEN"} {
+			if _, err := vault.Get(name); err != secrets.ErrNotFound {
+				t.Errorf("Get(%s) error = %v, want %v", name, err, secrets.ErrNotFound)
+			}
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/huangdijia/ccswitch/internal/termui"
+	"github.com/spf13/cobra"
+)
+
+// selectItem asks the user to pick one of cfg.Items on the command's input
+// and output: with the interactive selector on a terminal, and with a
+// numbered menu otherwise or when --no-tui is passed
+func selectItem(cmd *cobra.Command, cfg termui.SelectConfig) (string, error) {
+	cfg.In = cmd.InOrStdin()
+	cfg.Out = cmd.OutOrStdout()
+	return termui.Select(cfg, !noTUIRequested(cmd))
+}
+
+// selectItems is selectItem for picking several items
+func selectItems(cmd *cobra.Command, cfg termui.SelectConfig) ([]string, error) {
+	cfg.In = cmd.InOrStdin()
+	cfg.Out = cmd.OutOrStdout()
+	return termui.SelectMany(cfg, !noTUIRequested(cmd))
+}
+
+// noTUIRequested reports whether --no-tui was passed
+func noTUIRequested(cmd *cobra.Command) bool {
+	f := cmd.Flag("no-tui")
+	return f != nil && f.Value.String() == "true"
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	setFromEnv []string
+	setStdin   string
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <profile> [KEY=VALUE...]",
+	Short: "Set environment variables in a profile",
+	Long: `Set one or more environment variables in a profile.
+
+Known Claude Code variables are checked before saving: URLs must be http or
+https, timeouts and token limits must be integers and switches must be 1, 0,
+true or false. Tokens are moved into the secrets backend when one is configured.
+
+Example:
+  ccswitch set glm API_TIMEOUT_MS=600000 CLAUDE_CODE_MAX_OUTPUT_TOKENS=32000
+  ccswitch set glm --from-env HTTPS_PROXY
+  pass show glm | ccswitch set glm --stdin ANTHROPIC_AUTH_TOKEN`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profileName := args[0]
+		env, err := parseAssignments(args[1:])
+		if err != nil {
+			return err
+		}
+		for _, key := range setFromEnv {
+			value, ok := os.LookupEnv(key)
+			if !ok {
+				return fmt.Errorf("%s is not set in the current environment", key)
+			}
+			env[key] = value
+		}
+		if setStdin != "" {
+			value, err := readStdinValue(cmd, setStdin)
+			if err != nil {
+				return err
+			}
+			env[setStdin] = value
+		}
+		if len(env) == 0 {
+			return fmt.Errorf("nothing to set: pass KEY=VALUE pairs, --from-env or --stdin")
+		}
+
+		var own map[string]string
+		err = cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			if err := cmdutil.ValidateProfile(profs, profileName); err != nil {
+				return err
+			}
+			if err := profs.SetEnv(profileName, env); err != nil {
+				return err
+			}
+			own = profs.Data.Profiles[profileName]
+			// Move tokens into the secret store when one is configured
+			return cmdutil.StoreSecrets(profs, profileName, own)
+		})
+		if err != nil {
+			return err
+		}
+
+		output.Success("Profile '%s' updated", profileName)
+		for _, key := range slices.Sorted(maps.Keys(env)) {
+			fmt.Printf("  %s=%s\n", key, maskDiffValue(key, own[key]))
+		}
+		return nil
+	},
+}
+
+// parseAssignments parses KEY=VALUE arguments; later assignments win
+func parseAssignments(args []string) (map[string]string, error) {
+	env := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid assignment '%s': expected KEY=VALUE", arg)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// readStdinValue reads the value for key from stdin, prompting without echo
+// when stdin is a terminal
+func readStdinValue(cmd *cobra.Command, key string) (string, error) {
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(os.Stderr, "Enter value for %s: ", key)
+		value, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		return string(value), nil
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from stdin: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func init() {
+	setCmd.Flags().StringSliceVar(&setFromEnv, "from-env", nil, "Copy variables from the current environment (repeatable)")
+	setCmd.Flags().StringVar(&setStdin, "stdin", "", "Read the value of this variable from stdin")
+}
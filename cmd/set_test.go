@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestSetCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(setCmd)
+
+	reset := func() {
+		setFromEnv = nil
+		setStdin = ""
+	}
+	t.Cleanup(reset)
+
+	t.Run("assignments, environment and stdin", func(t *testing.T) {
+		reset()
+		t.Setenv("HTTPS_PROXY", "http://127.0.0.1:7890")
+		rootCmd.SetIn(strings.NewReader("sk-from-stdin\n"))
+		rootCmd.SetArgs([]string{"set", "test-profile", "API_TIMEOUT_MS=600000", "NOTE=a=b",
+			"--from-env", "HTTPS_PROXY", "--stdin", "ANTHROPIC_AUTH_TOKEN", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("set command failed: %v", err)
+		}
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := profs.Data.Profiles["test-profile"]
+		want := map[string]string{
+			"API_TIMEOUT_MS":       "600000",
+			"NOTE":                 "a=b",
+			"HTTPS_PROXY":          "http://127.0.0.1:7890",
+			"ANTHROPIC_AUTH_TOKEN": "sk-from-stdin",
+		}
+		for key, value := range want {
+			if env[key] != value {
+				t.Errorf("%s = %q, want %q", key, env[key], value)
+			}
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"set", "test-profile", "API_TIMEOUT_MS=10m", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for invalid value, got nil")
+		}
+	})
+
+	t.Run("missing environment variable", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"set", "test-profile", "--from-env", "CCSWITCH_TEST_UNSET_VAR", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for unset environment variable, got nil")
+		}
+	})
+
+	t.Run("malformed assignment", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"set", "test-profile", "API_TIMEOUT_MS", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for malformed assignment, got nil")
+		}
+	})
+}
+
+func TestUnsetCommand(t *testing.T) {
+	_, profilesPath, _ := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(unsetCmd)
+
+	rootCmd.SetArgs([]string{"unset", "test-profile", "ANTHROPIC_SMALL_FAST_MODEL", "-p", profilesPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("unset command failed: %v", err)
+	}
+
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := profs.Data.Profiles["test-profile"]["ANTHROPIC_SMALL_FAST_MODEL"]; ok {
+		t.Error("ANTHROPIC_SMALL_FAST_MODEL was not removed")
+	}
+
+	rootCmd.SetArgs([]string{"unset", "test-profile", "NOT_SET", "-p", profilesPath})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for a key that is not set, got nil")
+	}
+}
@@ -1,15 +1,24 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/huangdijia/ccswitch/internal/cmdutil"
 	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/pin"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
 var (
 	showCurrent bool
+	showSources bool
 )
 
 var showCmd = &cobra.Command{
@@ -19,18 +28,29 @@ var showCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profilesPath := cmd.Flag("profiles").Value.String()
 		settingsPath := cmd.Flag("settings").Value.String()
+		reveal := revealSecrets(cmd)
+
+		format, err := resultFormat(cmd)
+		if err != nil {
+			return err
+		}
 
 		// Show current settings if requested or if no profile name provided
 		if showCurrent || len(args) == 0 {
-			settingsPath = cmdutil.ResolveSettingsPath(settingsPath, profilesPath)
-
-			currentSettings, err := cmdutil.LoadSettings(settingsPath)
+			effective, err := cmdutil.EffectiveSettings(settingsPath, profilesPath)
 			if err != nil {
 				return err
 			}
+			currentSettings := effective.Settings
+			if format.Structured() {
+				return output.Write(cmd.OutOrStdout(), format, currentResult(profilesPath, effective, reveal))
+			}
 
 			fmt.Println("Current Claude Settings:")
-			fmt.Printf("  Settings file: %s\n", settingsPath)
+			fmt.Printf("  Settings file: %s\n", effective.Path)
+			fmt.Printf("  Effective scope: %s\n", effective.Scope)
+			printActiveProfile(profilesPath, effective.Path, currentSettings)
+			printProfileSelection()
 			fmt.Println()
 
 			if currentSettings.Model != "" {
@@ -39,7 +59,14 @@ var showCmd = &cobra.Command{
 				fmt.Println("  Model: (default)")
 			}
 
-			output.PrintEnvVariables(currentSettings.Env)
+			if reveal && len(currentSettings.Env) > 0 {
+				fmt.Println("\nEnvironment Variables:")
+				for _, key := range slices.Sorted(maps.Keys(currentSettings.Env)) {
+					fmt.Printf("  %s: %v\n", key, currentSettings.Env[key])
+				}
+			} else {
+				output.PrintEnvVariables(currentSettings.Env)
+			}
 
 			return nil
 		}
@@ -55,7 +82,14 @@ var showCmd = &cobra.Command{
 			return err
 		}
 
-		profileData := profs.Get(profileName)
+		resolved, err := profs.Resolve(profileName)
+		if err != nil {
+			return err
+		}
+		if format.Structured() {
+			return output.Write(cmd.OutOrStdout(), format, profileResult(profs, profileName, resolved, reveal))
+		}
+		profileData := resolved.Env
 		descriptions := profs.Data.Descriptions
 
 		fmt.Printf("Profile: %s\n", profileName)
@@ -68,14 +102,35 @@ var showCmd = &cobra.Command{
 			fmt.Printf("  Description: %s\n", desc)
 		}
 
+		if parents := profs.Data.Extends[profileName]; len(parents) > 0 {
+			fmt.Printf("  Extends: %s\n", strings.Join(parents, ", "))
+		}
+
+		if len(resolved.ModelMap) > 0 {
+			fmt.Println("\nModel map:")
+			printModelMap(resolved.ModelMap, profileName)
+		}
+
+		if len(resolved.Settings) > 0 {
+			data, err := json.MarshalIndent(resolved.Settings, "  ", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nSettings:\n  %s\n", data)
+		}
+
 		fmt.Println("\nConfiguration:")
 
 		if len(profileData) > 0 {
 			for key, value := range profileData {
 				// Hide sensitive information
-				if output.IsSensitiveKey(key) {
+				if !reveal && output.IsSensitiveKey(key) && !secrets.IsRef(value) {
 					value = output.MaskSensitiveValue(value)
 				}
+				if showSources && resolved.Sources[key] != profileName {
+					fmt.Printf("  %s: %s  [from %s]\n", key, value, resolved.Sources[key])
+					continue
+				}
 				fmt.Printf("  %s: %s\n", key, value)
 			}
 		} else {
@@ -86,6 +141,96 @@ var showCmd = &cobra.Command{
 	},
 }
 
+// currentResult describes the effective settings and the profile they match
+func currentResult(profilesPath string, effective *cmdutil.ScopedSettings, reveal bool) *output.CurrentSettings {
+	s := effective.Settings
+	result := &output.CurrentSettings{
+		SettingsPath: effective.Path,
+		Scope:        string(effective.Scope),
+		ShellProfile: os.Getenv(ProfileEnvVar),
+		Model:        s.Model,
+		Env:          make(map[string]string, len(s.Env)),
+	}
+	for key, value := range s.Env {
+		result.Env[key] = displayValue(key, fmt.Sprint(value), reveal)
+	}
+
+	if profs, err := profiles.New(profilesPath); err == nil {
+		if match := detectActiveProfile(profs, s); match != nil {
+			result.Active = match.Profile
+			result.Drift = differences(match.Diffs, reveal)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if p, err := pin.Find(cwd); err == nil && p != nil {
+			result.PinnedProfile = p.Profile
+		}
+	}
+	return result
+}
+
+// profileResult describes a resolved profile
+func profileResult(profs *profiles.Profiles, profileName string, resolved *profiles.Resolved, reveal bool) *output.Profile {
+	result := &output.Profile{
+		Name:        profileName,
+		Description: profs.Data.Descriptions[profileName],
+		Default:     profileName == profs.Default(),
+		Extends:     profs.Data.Extends[profileName],
+		Env:         displayEnv(resolved.Env, reveal),
+		Settings:    resolved.Settings,
+	}
+	for key, source := range resolved.Sources {
+		if source != profileName {
+			if result.Sources == nil {
+				result.Sources = make(map[string]string)
+			}
+			result.Sources[key] = source
+		}
+	}
+	for _, rule := range resolved.ModelMap {
+		result.ModelMap = append(result.ModelMap, output.ModelRule{Pattern: rule.Pattern, Model: rule.Model, Source: rule.Source})
+	}
+	if resolved.TokenCommand != nil {
+		result.TokenCommand = resolved.TokenCommand.Command
+	}
+	return result
+}
+
+// printModelMap prints the rules in the order they are matched, noting
+// rules inherited from other profiles
+func printModelMap(modelMap profiles.ModelMap, profileName string) {
+	width := 0
+	for _, rule := range modelMap {
+		width = max(width, len(rule.Pattern))
+	}
+	for _, rule := range modelMap {
+		if rule.Source != profileName {
+			fmt.Printf("  %-*s -> %s  [from %s]\n", width, rule.Pattern, rule.Model, rule.Source)
+			continue
+		}
+		fmt.Printf("  %-*s -> %s\n", width, rule.Pattern, rule.Model)
+	}
+}
+
+// printProfileSelection reports the profile activated in the current shell
+// and the pin file that applies to the current directory, if any
+func printProfileSelection() {
+	if active := os.Getenv(ProfileEnvVar); active != "" {
+		if pinPath := os.Getenv(PinEnvVar); pinPath != "" {
+			fmt.Printf("  Shell profile: %s (selected by %s)\n", active, pinPath)
+		} else {
+			fmt.Printf("  Shell profile: %s\n", active)
+		}
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if p, err := pin.Find(cwd); err == nil && p != nil {
+			fmt.Printf("  Pinned profile: %s (selected by %s)\n", p.Profile, p.Path)
+		}
+	}
+}
+
 func init() {
 	showCmd.Flags().BoolVarP(&showCurrent, "current", "c", false, "Show current Claude settings instead of a profile")
+	showCmd.Flags().BoolVar(&showSources, "sources", false, "Show which profile each inherited value comes from")
 }
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
 )
 
 func TestMaskSensitiveValue(t *testing.T) {
@@ -58,3 +63,80 @@ func TestMaskSensitiveValue(t *testing.T) {
 		})
 	}
 }
+
+func TestShowCommandOutput(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.SetEnv("test-profile", map[string]string{"ANTHROPIC_AUTH_TOKEN": "sk-test-1234567890"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.PersistentFlags().String("output", "table", "output format")
+	rootCmd.PersistentFlags().Bool("reveal", false, "reveal secrets")
+	rootCmd.AddCommand(showCmd, useCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	defer func() { showCurrent = false }()
+
+	showProfile := func(t *testing.T, args ...string) output.Profile {
+		t.Helper()
+		out.Reset()
+		rootCmd.SetArgs(append([]string{"show", "test-profile", "-p", profilesPath, "-s", settingsPath}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("show command failed: %v", err)
+		}
+		var result output.Profile
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+		}
+		return result
+	}
+
+	t.Run("profile masked", func(t *testing.T) {
+		result := showProfile(t, "--output", "json", "--reveal=false")
+		if result.Name != "test-profile" || !result.Default || result.Env["ANTHROPIC_MODEL"] != "test-model" {
+			t.Errorf("result = %+v", result)
+		}
+		if token := result.Env["ANTHROPIC_AUTH_TOKEN"]; token == "sk-test-1234567890" || token == "" {
+			t.Errorf("token = %q, want it masked", token)
+		}
+	})
+
+	t.Run("profile revealed", func(t *testing.T) {
+		result := showProfile(t, "--output", "json", "--reveal")
+		if token := result.Env["ANTHROPIC_AUTH_TOKEN"]; token != "sk-test-1234567890" {
+			t.Errorf("token = %q, want it revealed", token)
+		}
+	})
+
+	t.Run("current yaml", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+
+		out.Reset()
+		rootCmd.SetArgs([]string{"show", "--current", "--output", "yaml", "--reveal=false", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("show command failed: %v", err)
+		}
+		got := out.String()
+		for _, want := range []string{"active: test-profile\n", "model: test-model\n", "scope: user\n", "  ANTHROPIC_AUTH_TOKEN: sk-t**********7890\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("output does not contain %q:\n%s", want, got)
+			}
+		}
+	})
+}
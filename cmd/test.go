@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/probe"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/termui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testAll         bool
+	testConcurrency int
+	testTimeout     time.Duration
+)
+
+// profileReport holds the probe results of one profile
+type profileReport struct {
+	name    string
+	baseURL string
+	results []probe.Result
+	err     error
+	// env is the resolved environment the profile is probed with
+	env map[string]string
+}
+
+// failures counts the failed checks of the report
+func (r profileReport) failures() int {
+	if r.err != nil {
+		return 1
+	}
+	n := 0
+	for _, result := range r.results {
+		if !result.OK() {
+			n++
+		}
+	}
+	return n
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [profile...]",
+	Short: "Check that profiles' endpoints and credentials work",
+	Long: `Send a minimal Messages API request to the profile's endpoint for every
+configured model (ANTHROPIC_MODEL, ANTHROPIC_SMALL_FAST_MODEL and the
+ANTHROPIC_DEFAULT_*_MODEL keys) and report latency, HTTP status and the error
+body of failed requests.
+
+Several profiles are tested concurrently. Without arguments, the profiles to
+test are chosen interactively; use --all to test every profile.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		switch {
+		case testAll:
+			names = profs.GetAll()
+			sort.Strings(names)
+		case len(args) > 0:
+			for _, name := range args {
+				if err := cmdutil.ValidateProfile(profs, name); err != nil {
+					return err
+				}
+			}
+			names = args
+		default:
+			available := profs.GetAll()
+			if len(available) == 0 {
+				return fmt.Errorf("no profiles available")
+			}
+			sort.Strings(available)
+
+			names, err = selectItems(cmd, termui.SelectConfig{
+				Prompt: "Select profiles to test:",
+				Items:  available,
+				Details: func(name string) string {
+					return profileDetails(profs.Data.Descriptions[name], profs.Get(name))
+				},
+			})
+			if err != nil {
+				if err == termui.ErrCanceled {
+					return nil
+				}
+				if err == termui.ErrNoInput {
+					return fmt.Errorf("no profile specified (use 'ccswitch test <profile>' or --all)")
+				}
+				return err
+			}
+		}
+
+		if len(names) == 0 {
+			return fmt.Errorf("no profiles available")
+		}
+
+		client := &http.Client{Timeout: testTimeout}
+		reports := testProfiles(cmd.Context(), client, profs, names, testConcurrency)
+
+		failed := 0
+		for _, report := range reports {
+			printProfileReport(report)
+			failed += report.failures()
+		}
+
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+// testProfiles probes the named profiles using at most concurrency workers
+// and returns the reports in the order of names. Profiles are resolved up
+// front, running token commands, so that a secret store prompt never happens
+// inside a worker.
+func testProfiles(ctx context.Context, client *http.Client, profs *profiles.Profiles, names []string, concurrency int) []profileReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	resolver := cmdutil.NewSecretResolver(profs)
+	reports := make([]profileReport, len(names))
+	for i, name := range names {
+		reports[i] = profileReport{name: name}
+		resolved, err := profs.Resolve(name)
+		if err != nil {
+			reports[i].err = err
+			continue
+		}
+		env := profs.Get(name)
+		if err := applyToken(ctx, profs, name, resolved.TokenCommand, env); err != nil {
+			reports[i].err = err
+			continue
+		}
+		env, err = resolver.Resolve(env)
+		if err != nil {
+			reports[i].err = err
+			continue
+		}
+		reports[i].env = env
+		reports[i].baseURL = probe.TargetFromEnv(env).BaseURL
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				reports[i].results = probe.Profile(ctx, client, reports[i].env)
+			}
+		}()
+	}
+
+	for i := range reports {
+		if reports[i].err == nil {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return reports
+}
+
+// printProfileReport prints one line per probed model
+func printProfileReport(report profileReport) {
+	if report.err != nil {
+		fmt.Printf("Profile: %s\n  ✗ %v\n\n", report.name, report.err)
+		return
+	}
+
+	fmt.Printf("Profile: %s (%s)\n", report.name, report.baseURL)
+	for _, r := range report.results {
+		mark := "✓"
+		if !r.OK() {
+			mark = "✗"
+		}
+		status := "---"
+		if r.StatusCode != 0 {
+			status = fmt.Sprintf("%d", r.StatusCode)
+		}
+		model := r.Model
+		if model == "" {
+			model = "(none)"
+		}
+		fmt.Printf("  %s %-30s %s %6dms  %s\n", mark, model, status, r.Latency.Milliseconds(), strings.Join(r.Keys, ", "))
+		if r.Err != nil {
+			fmt.Printf("      error: %v\n", r.Err)
+		} else if r.Body != "" {
+			fmt.Printf("      response: %s\n", r.Body)
+		}
+	}
+	fmt.Println()
+}
+
+func init() {
+	testCmd.Flags().BoolVarP(&testAll, "all", "a", false, "Test every profile")
+	testCmd.Flags().IntVarP(&testConcurrency, "concurrency", "c", 4, "Number of profiles tested in parallel with --all")
+	testCmd.Flags().DurationVarP(&testTimeout, "timeout", "t", 30*time.Second, "Timeout for each request")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func TestTestCommand(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid token"}`))
+			return
+		}
+		w.Write([]byte(`{"type":"message"}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	profilesConfig := map[string]any{
+		"profiles": map[string]any{
+			"good-1": map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "good", "ANTHROPIC_MODEL": "m"},
+			"good-2": map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "good", "ANTHROPIC_MODEL": "m"},
+			"good-3": map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "good", "ANTHROPIC_MODEL": "m"},
+			"bad":    map[string]string{"ANTHROPIC_BASE_URL": server.URL, "ANTHROPIC_AUTH_TOKEN": "bad", "ANTHROPIC_MODEL": "m"},
+		},
+	}
+	profilesData, _ := json.MarshalIndent(profilesConfig, "", "    ")
+	if err := os.WriteFile(profilesPath, profilesData, 0644); err != nil {
+		t.Fatalf("Failed to write profiles config: %v", err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.AddCommand(testCmd)
+
+	reset := func() {
+		testAll = false
+		testConcurrency = 4
+		testTimeout = 5 * time.Second
+	}
+
+	t.Run("healthy profile", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"test", "good-1", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("test command failed: %v", err)
+		}
+	})
+
+	t.Run("unauthorized profile fails", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"test", "bad", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for unauthorized profile, got nil")
+		}
+	})
+
+	t.Run("several profiles", func(t *testing.T) {
+		reset()
+		rootCmd.SetArgs([]string{"test", "good-1", "good-2", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("test command failed: %v", err)
+		}
+	})
+
+	t.Run("profiles chosen from the menu", func(t *testing.T) {
+		reset()
+		defer rootCmd.SetIn(nil)
+		// Items are sorted: bad, good-1, good-2, good-3
+		rootCmd.SetIn(strings.NewReader("2-4\n"))
+		rootCmd.SetArgs([]string{"test", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("test command failed: %v", err)
+		}
+
+		rootCmd.SetIn(strings.NewReader(""))
+		rootCmd.SetArgs([]string{"test", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error without a profile, got nil")
+		}
+	})
+
+	t.Run("all profiles with bounded concurrency", func(t *testing.T) {
+		reset()
+		atomic.StoreInt32(&maxInFlight, 0)
+		rootCmd.SetArgs([]string{"test", "--all", "--concurrency", "2", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error because one profile fails, got nil")
+		}
+		if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+			t.Errorf("max concurrent requests = %d, want at most 2", got)
+		}
+	})
+}
+
+func TestTestProfilesOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	_, profilesPath, _ := setupTestEnvironment(t)
+	profs, err := cmdutil.LoadProfiles(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Profiles["test-profile"]["ANTHROPIC_BASE_URL"] = server.URL
+	profs.Data.Profiles["another-profile"]["ANTHROPIC_BASE_URL"] = server.URL
+
+	names := []string{"test-profile", "another-profile"}
+	reports := testProfiles(context.Background(), server.Client(), profs, names, 8)
+	for i, report := range reports {
+		if report.name != names[i] {
+			t.Errorf("reports[%d].name = %v, want %v", i, report.name, names[i])
+		}
+		if report.failures() != 0 {
+			t.Errorf("reports[%d] has %d failures: %+v", i, report.failures(), report.results)
+		}
+	}
+}
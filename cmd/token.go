@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/huangdijia/ccswitch/internal/shell"
+	"github.com/huangdijia/ccswitch/internal/tokencache"
+	"github.com/spf13/cobra"
+)
+
+// tokenCommandTimeout bounds how long a token command may run
+const tokenCommandTimeout = 30 * time.Second
+
+var tokenRefresh bool
+
+var tokenCmd = &cobra.Command{
+	Use:   "token <profile>",
+	Short: "Print a profile's token from its token command",
+	Long: `Print a short-lived token for a profile by running its "tokenCommand".
+
+The token is cached next to ccs.json (tokens/) and reused until its ttl, 5
+minutes by default, runs out. "ccswitch use" points Claude Code's apiKeyHelper
+at this command for profiles with a token command, so long-lived tokens are
+never written to settings.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profs, err := cmdutil.LoadProfiles(profilesPath)
+		if err != nil {
+			return err
+		}
+
+		profileName := args[0]
+		if err := cmdutil.ValidateProfile(profs, profileName); err != nil {
+			return err
+		}
+
+		resolved, err := profs.Resolve(profileName)
+		if err != nil {
+			return err
+		}
+		token, err := fetchToken(cmd.Context(), profs, profileName, resolved.TokenCommand, tokenRefresh)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), token)
+		return nil
+	},
+}
+
+// fetchToken returns the token of a profile's token command, from the cache
+// unless refresh is set
+func fetchToken(ctx context.Context, profs *profiles.Profiles, profileName string, command *profiles.TokenCommand, refresh bool) (string, error) {
+	if command == nil || command.Command == "" {
+		return "", fmt.Errorf("profile '%s' has no tokenCommand", profileName)
+	}
+	ttl, err := command.CacheTTL()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	cache := &tokencache.Cache{Dir: tokencache.Dir(profs.Path)}
+	return cache.Token(ctx, profileName, command.Command, ttl, refresh)
+}
+
+// applyToken puts the token of a profile's token command into env in place
+// of the tokens the profile holds, for commands that hand the environment to
+// a client or endpoint directly instead of through apiKeyHelper. The token
+// replaces ANTHROPIC_API_KEY if the profile only sets that key, and
+// ANTHROPIC_AUTH_TOKEN otherwise. Nothing changes when command is nil
+func applyToken(ctx context.Context, profs *profiles.Profiles, profileName string, command *profiles.TokenCommand, env map[string]string) error {
+	if command == nil {
+		return nil
+	}
+	token, err := fetchToken(ctx, profs, profileName, command, false)
+	if err != nil {
+		return err
+	}
+
+	key := "ANTHROPIC_AUTH_TOKEN"
+	if _, ok := env[key]; !ok {
+		if _, ok := env["ANTHROPIC_API_KEY"]; ok {
+			key = "ANTHROPIC_API_KEY"
+		}
+	}
+	for _, k := range secrets.Keys {
+		delete(env, k)
+	}
+	env[key] = token
+	return nil
+}
+
+// tokenHelperCommand returns the apiKeyHelper command line that prints the
+// profile's token
+func tokenHelperCommand(profs *profiles.Profiles, profileName string) string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "ccswitch"
+	}
+	profilesPath := profs.Path
+	if abs, err := filepath.Abs(profilesPath); err == nil {
+		profilesPath = abs
+	}
+
+	// Claude Code runs the helper through the system shell
+	quote := func(arg string) string { return shell.Quote(shell.Bash, arg) }
+	if runtime.GOOS == "windows" {
+		quote = func(arg string) string { return `"` + arg + `"` }
+	}
+	return strings.Join([]string{quote(executable), "token", quote(profileName), "--profiles", quote(profilesPath)}, " ")
+}
+
+// applyTokenHelperEnv replaces the tokens in env, as written for a profile
+// with a token command, by the interval at which Claude Code calls the helper
+func applyTokenHelperEnv(env map[string]string, command *profiles.TokenCommand) error {
+	ttl, err := command.CacheTTL()
+	if err != nil {
+		return err
+	}
+	for _, key := range secrets.Keys {
+		delete(env, key)
+	}
+	env["CLAUDE_CODE_API_KEY_HELPER_TTL_MS"] = strconv.FormatInt(ttl.Milliseconds(), 10)
+	return nil
+}
+
+func init() {
+	tokenCmd.Flags().BoolVar(&tokenRefresh, "refresh", false, "Run the token command even if a cached token is still valid")
+}
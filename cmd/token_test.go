@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+func TestTokenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.TokenCommands = map[string]profiles.TokenCommand{
+		"test-profile": {Command: "echo short-lived-token", TTL: "15m"},
+	}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(tokenCmd, useCmd)
+
+	t.Run("print token", func(t *testing.T) {
+		defer func() { tokenRefresh = false }()
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		defer rootCmd.SetOut(nil)
+
+		rootCmd.SetArgs([]string{"token", "test-profile", "--refresh", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("token command failed: %v", err)
+		}
+		if got := strings.TrimSpace(out.String()); got != "short-lived-token" {
+			t.Errorf("token = %q, want short-lived-token", got)
+		}
+	})
+
+	t.Run("profile without token command", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"token", "another-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("expected error for a profile without tokenCommand")
+		}
+	})
+
+	t.Run("use writes apiKeyHelper", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s struct {
+			APIKeyHelper string            `json:"apiKeyHelper"`
+			Env          map[string]string `json:"env"`
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(s.APIKeyHelper, " token 'test-profile' --profiles ") {
+			t.Errorf("apiKeyHelper = %q", s.APIKeyHelper)
+		}
+		if s.Env["CLAUDE_CODE_API_KEY_HELPER_TTL_MS"] != "900000" {
+			t.Errorf("CLAUDE_CODE_API_KEY_HELPER_TTL_MS = %q, want 900000", s.Env["CLAUDE_CODE_API_KEY_HELPER_TTL_MS"])
+		}
+		if _, ok := s.Env["ANTHROPIC_AUTH_TOKEN"]; ok {
+			t.Error("ANTHROPIC_AUTH_TOKEN written for a profile with a token command")
+		}
+	})
+	t.Run("env exports the token", func(t *testing.T) {
+		envShell = "bash"
+		defer func() { envShell = "" }()
+		rootCmd.AddCommand(envCmd)
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		defer rootCmd.SetOut(nil)
+
+		rootCmd.SetArgs([]string{"env", "test-profile", "-p", profilesPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("env command failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "export ANTHROPIC_AUTH_TOKEN='short-lived-token'") {
+			t.Errorf("env output does not export the token:\n%s", out.String())
+		}
+	})
+
+	t.Run("test probes with the token", func(t *testing.T) {
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.Write([]byte(`{"type":"message"}`))
+		}))
+		defer server.Close()
+
+		profs, err := profiles.New(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := profs.SetEnv("test-profile", map[string]string{"ANTHROPIC_BASE_URL": server.URL}); err != nil {
+			t.Fatal(err)
+		}
+
+		reports := testProfiles(context.Background(), server.Client(), profs, []string{"test-profile"}, 1)
+		if reports[0].err != nil || reports[0].failures() > 0 {
+			t.Errorf("test-profile report = %+v", reports[0])
+		}
+		if authorization != "Bearer short-lived-token" {
+			t.Errorf("Authorization = %q, want the token command's token", authorization)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset <profile> KEY...",
+	Short: "Remove environment variables from a profile",
+	Long: `Remove one or more environment variables from a profile.
+
+Only variables the profile sets itself can be removed; inherited variables
+must be unset in the profile that defines them.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profilesPath := cmd.Flag("profiles").Value.String()
+
+		profileName := args[0]
+		err := cmdutil.UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			if err := cmdutil.ValidateProfile(profs, profileName); err != nil {
+				return err
+			}
+			return profs.UnsetEnv(profileName, args[1:])
+		})
+		if err != nil {
+			return err
+		}
+
+		output.Success("Profile '%s' updated", profileName)
+		for _, key := range args[1:] {
+			fmt.Printf("  %s removed\n", key)
+		}
+		return nil
+	},
+}
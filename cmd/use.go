@@ -2,23 +2,58 @@ package cmd
 
 import (
 	"fmt"
+	"log"
+	"maps"
+	"net/http"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/huangdijia/ccswitch/internal/cmdutil"
+	"github.com/huangdijia/ccswitch/internal/history"
 	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/ownership"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/proxy"
+	"github.com/huangdijia/ccswitch/internal/settings"
 	"github.com/huangdijia/ccswitch/internal/termui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	useScope    string
+	useFallback bool
+	useForce    bool
+)
+
 var useCmd = &cobra.Command{
 	Use:   "use [profile]",
 	Short: "Switch the active Claude API profile",
-	Long:  "This command allows you to set the active Claude API profile",
+	Long: `This command allows you to set the active Claude API profile.
+
+Use --scope to choose which settings file receives the profile:
+  user     the global Claude settings file (default)
+  project  .claude/settings.json in the current git worktree
+  local    .claude/settings.local.json in the current git worktree
+
+Use "ccswitch use -" to switch back to the previously active profile.
+
+With --fallback the profile's endpoint is checked first; if it is unhealthy,
+the first healthy profile from its "fallbacks" list is used instead.
+
+Profiles are validated before switching; use --force to switch to a profile
+with errors anyway (see "ccswitch doctor").`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profilesPath := cmd.Flag("profiles").Value.String()
 		settingsPath := cmd.Flag("settings").Value.String()
 
+		scope, err := settings.ParseScope(useScope)
+		if err != nil {
+			return err
+		}
+
 		profs, err := cmdutil.LoadProfiles(profilesPath)
 		if err != nil {
 			return err
@@ -44,25 +79,19 @@ var useCmd = &cobra.Command{
 				}
 			}
 
-			inFile, inOK := cmd.InOrStdin().(*os.File)
-			outFile, outOK := cmd.OutOrStdout().(*os.File)
-			if !inOK || !outOK {
-				return fmt.Errorf("no profile specified (use 'ccswitch use <profile>')")
-			}
-
-			selected, err := termui.SelectString(termui.SelectConfig{
-				In:           inFile,
-				Out:          outFile,
+			selected, err := selectItem(cmd, termui.SelectConfig{
 				Prompt:       "Select profile:",
-				Hint:         "↑/↓ to move, Enter to select, q to cancel",
 				Items:        availableProfiles,
 				DefaultIndex: defaultIndex,
+				Details: func(name string) string {
+					return profileDetails(profs.Data.Descriptions[name], profs.Get(name))
+				},
 			})
 			if err != nil {
 				if err == termui.ErrCanceled {
 					return nil
 				}
-				if err == termui.ErrNotTerminal {
+				if err == termui.ErrNoInput {
 					return fmt.Errorf("no profile specified (use 'ccswitch use <profile>')")
 				}
 				return err
@@ -72,45 +101,224 @@ var useCmd = &cobra.Command{
 			profileName = args[0]
 		}
 
+		// "use -" switches back to the previous profile, like "cd -"
+		if profileName == "-" {
+			if profileName, err = previousProfile(profs.Path, scope, settingsPath, profilesPath); err != nil {
+				return err
+			}
+		}
+
 		if err := cmdutil.ValidateProfile(profs, profileName); err != nil {
 			return err
 		}
 
-		settingsPath = cmdutil.ResolveSettingsPath(settingsPath, profilesPath)
+		if useFallback {
+			client := &http.Client{Timeout: 30 * time.Second}
+			logger := log.New(cmd.OutOrStdout(), "", 0)
+			if profileName, err = firstHealthyProfile(cmd.Context(), client, profs, profileName, logger); err != nil {
+				return err
+			}
+		}
 
-		currentSettings, err := cmdutil.LoadSettings(settingsPath)
-		if err != nil {
+		if err := checkProfile(profs, profileName, useForce); err != nil {
 			return err
 		}
 
-		// Get the environment variables for the selected profile
-		env := profs.Get(profileName)
+		return applyProfile(profs, profileName, applyOptions{
+			scope:        scope,
+			settingsPath: settingsPath,
+			profilesPath: profilesPath,
+		})
+	},
+}
 
-		// Convert map[string]string to map[string]any
-		envInterface := make(map[string]any)
-		for k, v := range env {
-			envInterface[k] = v
-		}
+// checkProfile validates a profile before switching to it. Warnings are
+// printed; errors are printed and refuse the switch unless force is set
+func checkProfile(profs *profiles.Profiles, profileName string, force bool) error {
+	issues := profs.Validate(profileName)
+	if profiles.HasErrors(issues) && !force {
+		printIssues(os.Stdout, issues, "  ")
+		return fmt.Errorf("profile '%s' is invalid; fix the issues above or use --force to switch anyway", profileName)
+	}
+	for _, issue := range issues {
+		fmt.Printf("Warning: %s\n", issue)
+	}
+	return nil
+}
 
-		currentSettings.Env = envInterface
+// applyOptions controls where and how applyProfile writes a profile
+type applyOptions struct {
+	scope        settings.Scope
+	settingsPath string
+	profilesPath string
+	// overrides are applied on top of the profile's environment
+	overrides map[string]string
+}
 
-		// Handle model setting
-		if model, ok := env["ANTHROPIC_MODEL"]; ok {
-			currentSettings.Model = model
-		} else {
-			currentSettings.Model = ""
-		}
+// applyProfile writes a profile's environment into the settings file of the
+// requested scope and prints a summary
+func applyProfile(profs *profiles.Profiles, profileName string, opts applyOptions) error {
+	settingsPath, err := cmdutil.ResolveScopedSettingsPath(opts.scope, opts.settingsPath, opts.profilesPath)
+	if err != nil {
+		return err
+	}
+
+	// Hold the settings lock until the history and ownership are recorded,
+	// so that concurrent switches cannot interleave
+	unlock, err := cmdutil.LockSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	currentSettings, err := cmdutil.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	// Get the environment variables for the selected profile,
+	// resolving secret references right before they are written
+	resolved, err := profs.Resolve(profileName)
+	if err != nil {
+		return err
+	}
+	env := resolved.Env
+	for k, v := range opts.overrides {
+		env[k] = v
+	}
 
-		// Write settings
-		if err := currentSettings.Write(); err != nil {
+	// While a proxy is running, point the client at it and redirect the
+	// proxy instead of writing the profile's credentials
+	statePath := proxy.StatePath(profs.Path)
+	proxyState, err := proxy.Running(statePath)
+	if err != nil {
+		return err
+	}
+	if proxyState != nil {
+		proxyState.Profile = profileName
+		if err := proxyState.Save(statePath); err != nil {
 			return err
 		}
+		env = proxy.ClientEnv(env, proxyState)
+	} else {
+		// Profiles with a token command get short-lived tokens through
+		// apiKeyHelper, so no token is written to the settings
+		if resolved.TokenCommand != nil {
+			if err := applyTokenHelperEnv(env, resolved.TokenCommand); err != nil {
+				return err
+			}
+			helper := tokenHelperCommand(profs, profileName)
+			resolved.Settings = settings.DeepMerge(resolved.Settings, map[string]any{"apiKeyHelper": helper})
+		}
+		if env, err = cmdutil.ResolveSecrets(profs, env); err != nil {
+			return err
+		}
+	}
+
+	// Only replace what ccswitch wrote before, keeping keys set by the user
+	previous := appliedRecord(profs.Path, profs, currentSettings)
+	removeOwned(currentSettings, previous)
+	var preserved []string
+	for k := range currentSettings.Env {
+		if _, ok := env[k]; !ok {
+			preserved = append(preserved, k)
+		}
+	}
+	sort.Strings(preserved)
 
-		output.Success("Successfully switched to profile: %s", profileName)
+	replacedEnv := make(map[string]any)
+	for k, v := range env {
+		if old, ok := currentSettings.Env[k]; ok {
+			replacedEnv[k] = old
+		}
+		currentSettings.Env[k] = v
+	}
+	if model, ok := env["ANTHROPIC_MODEL"]; ok {
+		currentSettings.Model = model
+	}
+	replaced := currentSettings.Merge(resolved.Settings)
 
-		// Show profile details
-		output.PrintProfileDetails(env)
+	// Write settings
+	if err := currentSettings.Write(); err != nil {
+		return err
+	}
+	recordHistory(profs.Path, profileName, settingsPath, opts.scope)
+	recordOwnership(profs.Path, settingsPath, &ownership.Record{
+		Profile:     profileName,
+		Env:         slices.Sorted(maps.Keys(env)),
+		ReplacedEnv: replacedEnv,
+		Model:       env["ANTHROPIC_MODEL"],
+		Settings:    resolved.Settings,
+		Replaced:    replaced,
+	})
 
-		return nil
-	},
+	output.Success("Successfully switched to profile: %s", profileName)
+	if opts.scope != settings.ScopeUser {
+		fmt.Printf("  Scope: %s (%s)\n", opts.scope, settingsPath)
+	}
+	if proxyState != nil {
+		fmt.Printf("  Proxy: %s\n", proxyState.URL())
+	}
+	if len(resolved.Settings) > 0 {
+		fmt.Printf("  Settings: %s\n", strings.Join(slices.Sorted(maps.Keys(resolved.Settings)), ", "))
+	}
+	if len(preserved) > 0 {
+		fmt.Printf("  Preserved: %s\n", strings.Join(preserved, ", "))
+	}
+
+	// Show profile details
+	output.PrintProfileDetails(env)
+
+	return nil
+}
+
+// previousProfile returns the profile that was active in the scope's settings
+// file before the current one
+func previousProfile(historyBase string, scope settings.Scope, settingsPath, profilesPath string) (string, error) {
+	path, err := cmdutil.ResolveScopedSettingsPath(scope, settingsPath, profilesPath)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := history.Load(history.Path(historyBase))
+	if err != nil {
+		return "", err
+	}
+	previous := history.Previous(history.ForSettings(entries, path))
+	if previous == "" {
+		return "", fmt.Errorf("no previous profile in history for %s", path)
+	}
+	return previous, nil
+}
+
+// recordHistory appends a switch to the history file. Failures only warn
+// because the settings have already been written
+func recordHistory(profilesPath, profileName, settingsPath string, scope settings.Scope) {
+	err := history.Append(history.Path(profilesPath), history.Entry{
+		Time:         time.Now(),
+		Profile:      profileName,
+		SettingsPath: settingsPath,
+		Scope:        string(scope),
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to record history: %v\n", err)
+	}
+}
+
+// profileDetails describes a profile on one line for the interactive
+// selector: its description, URL and model
+func profileDetails(description string, env map[string]string) string {
+	var parts []string
+	for _, part := range []string{description, env["ANTHROPIC_BASE_URL"], env["ANTHROPIC_MODEL"]} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func init() {
+	useCmd.Flags().BoolVar(&useFallback, "fallback", false, "Check the profile's health and fall back to a healthy alternative")
+	useCmd.Flags().StringVar(&useScope, "scope", string(settings.ScopeUser), "Settings scope to write: user, project or local")
+	useCmd.Flags().BoolVarP(&useForce, "force", "f", false, "Switch even if the profile fails validation")
 }
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/huangdijia/ccswitch/internal/cmdutil"
 	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
 	"github.com/spf13/cobra"
 )
 
@@ -177,3 +182,300 @@ func TestUseCommandInteractive(t *testing.T) {
 		}
 	})
 }
+
+func TestUseCommandScope(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalWd, _ := os.Getwd()
+	os.Chdir(projectDir)
+	defer os.Chdir(originalWd)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+	defer func() { useScope = "user" }()
+
+	t.Run("local scope writes settings.local.json", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "test-profile", "--scope", "local", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(projectDir, ".claude", "settings.local.json"))
+		if err != nil {
+			t.Fatalf("Failed to read local settings: %v", err)
+		}
+		var settings map[string]any
+		if err := json.Unmarshal(data, &settings); err != nil {
+			t.Fatalf("Failed to parse local settings: %v", err)
+		}
+		if settings["model"] != "test-model" {
+			t.Errorf("Model not set correctly, got: %v", settings["model"])
+		}
+
+		if _, err := os.Stat(settingsPath); err == nil {
+			t.Error("User settings file should not be written for local scope")
+		}
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "test-profile", "--scope", "global", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for invalid scope, got nil")
+		}
+	})
+}
+
+func TestUseCommandValidation(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Profiles["test-profile"]["ANTHROPIC_AUTH_TOKEN"] = "sk-"
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+	t.Cleanup(func() { useForce = false })
+
+	t.Run("invalid profile is refused", func(t *testing.T) {
+		useForce = false
+		rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil {
+			t.Error("Expected error for invalid profile, got nil")
+		}
+		if _, err := os.Stat(settingsPath); err == nil {
+			t.Error("Settings file should not be written for an invalid profile")
+		}
+	})
+
+	t.Run("force switches anyway", func(t *testing.T) {
+		rootCmd.SetArgs([]string{"use", "test-profile", "--force", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use --force failed: %v", err)
+		}
+		if _, err := os.Stat(settingsPath); err != nil {
+			t.Errorf("Settings file was not written: %v", err)
+		}
+	})
+}
+
+func TestUseCommandSettings(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	profs, err := profiles.New(profilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profs.Data.Settings = map[string]map[string]any{
+		"test-profile":    {"apiKeyHelper": "/bin/test-token", "permissions": map[string]any{"allow": []any{"Read"}}},
+		"another-profile": {"includeCoAuthoredBy": false},
+	}
+	if err := profs.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(`{"theme": "dark", "apiKeyHelper": "/bin/user-token", "permissions": {"allow": ["Bash(ls)"], "deny": ["WebFetch"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd, resetCmd)
+
+	readSettings := func(t *testing.T) map[string]any {
+		t.Helper()
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s map[string]any
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	s := readSettings(t)
+	if s["apiKeyHelper"] != "/bin/test-token" || s["theme"] != "dark" {
+		t.Errorf("settings after use = %v", s)
+	}
+	permissions := s["permissions"].(map[string]any)
+	if !reflect.DeepEqual(permissions["allow"], []any{"Read"}) || permissions["deny"] == nil {
+		t.Errorf("permissions were not merged: %v", permissions)
+	}
+
+	rootCmd.SetArgs([]string{"use", "another-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	s = readSettings(t)
+	if s["apiKeyHelper"] != "/bin/user-token" {
+		t.Errorf("apiKeyHelper = %v, want the user's value restored", s["apiKeyHelper"])
+	}
+	if permissions := s["permissions"].(map[string]any); !reflect.DeepEqual(permissions["allow"], []any{"Bash(ls)"}) || permissions["deny"] == nil {
+		t.Errorf("permissions after switching = %v, want the user's lists restored", permissions)
+	}
+	if s["includeCoAuthoredBy"] != false {
+		t.Errorf("includeCoAuthoredBy = %v, want false", s["includeCoAuthoredBy"])
+	}
+
+	rootCmd.SetArgs([]string{"reset", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("reset command failed: %v", err)
+	}
+	s = readSettings(t)
+	if _, ok := s["includeCoAuthoredBy"]; ok {
+		t.Error("includeCoAuthoredBy was not removed by reset")
+	}
+	if s["theme"] != "dark" {
+		t.Errorf("theme = %v, want the user's value preserved", s["theme"])
+	}
+}
+
+func TestUseCommandKeepsUserKeys(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"DISABLE_TELEMETRY": "1"}, "model": "my-model"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+
+	readEnv := func(t *testing.T) (map[string]any, any) {
+		t.Helper()
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s map[string]any
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+		env, _ := s["env"].(map[string]any)
+		return env, s["model"]
+	}
+
+	rootCmd.SetArgs([]string{"use", "test-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	env, model := readEnv(t)
+	if env["DISABLE_TELEMETRY"] != "1" || env["ANTHROPIC_BASE_URL"] != "https://api.test.com" || model != "test-model" {
+		t.Errorf("after use test-profile: env = %v, model = %v", env, model)
+	}
+
+	// another-profile has no base URL, so test-profile's must not linger
+	rootCmd.SetArgs([]string{"use", "another-profile", "-p", profilesPath, "-s", settingsPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("use command failed: %v", err)
+	}
+	env, model = readEnv(t)
+	if _, ok := env["ANTHROPIC_BASE_URL"]; ok {
+		t.Error("ANTHROPIC_BASE_URL of the previous profile was not removed")
+	}
+	if env["DISABLE_TELEMETRY"] != "1" || model != "another-model" {
+		t.Errorf("after use another-profile: env = %v, model = %v", env, model)
+	}
+}
+
+func TestUseCommandMenu(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.PersistentFlags().Bool("no-tui", false, "numbered menu")
+	rootCmd.AddCommand(useCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetIn(nil)
+
+	t.Run("select by number", func(t *testing.T) {
+		rootCmd.SetIn(strings.NewReader("1\n"))
+		rootCmd.SetArgs([]string{"use", "--no-tui", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "  1) another-profile") || !strings.Contains(out.String(), "* 2) test-profile") {
+			t.Errorf("menu = %q", out.String())
+		}
+
+		s, err := settings.New(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Model != "another-model" {
+			t.Errorf("model = %q, want another-model", s.Model)
+		}
+	})
+
+	t.Run("select by name", func(t *testing.T) {
+		rootCmd.SetIn(strings.NewReader("test-profile\n"))
+		rootCmd.SetArgs([]string{"use", "--no-tui", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use command failed: %v", err)
+		}
+		s, err := settings.New(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Model != "test-model" {
+			t.Errorf("model = %q, want test-model", s.Model)
+		}
+	})
+
+	t.Run("no input", func(t *testing.T) {
+		rootCmd.SetIn(strings.NewReader(""))
+		rootCmd.SetArgs([]string{"use", "--no-tui", "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no profile specified") {
+			t.Errorf("error = %v, want no profile specified", err)
+		}
+	})
+}
+
+func TestUseCommandRestoresReplacedUserEnv(t *testing.T) {
+	_, profilesPath, settingsPath := setupTestEnvironment(t)
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"ANTHROPIC_BASE_URL": "https://user.example"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &cobra.Command{Use: "test"}
+	rootCmd.PersistentFlags().StringP("profiles", "p", profilesPath, "profiles path")
+	rootCmd.PersistentFlags().StringP("settings", "s", settingsPath, "settings path")
+	rootCmd.AddCommand(useCmd)
+
+	for _, profile := range []string{"test-profile", "another-profile"} {
+		rootCmd.SetArgs([]string{"use", profile, "-p", profilesPath, "-s", settingsPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("use %s failed: %v", profile, err)
+		}
+	}
+
+	s, err := cmdutil.LoadSettings(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Env["ANTHROPIC_BASE_URL"]; got != "https://user.example" {
+		t.Errorf("ANTHROPIC_BASE_URL = %v, want the user's value restored", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package atomicfile replaces files atomically under an advisory lock and
+// keeps backups of the content it replaces
+package atomicfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// lockTimeout is how long Lock waits for another process to release a lock
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age after which a lock file that names no process,
+	// because its holder crashed before writing its PID, is removed
+	staleLockAge = 30 * time.Second
+
+	// held lists the paths locked with Lock by this process, so that writes
+	// made while holding a lock do not wait for it
+	heldMu sync.Mutex
+	held   = make(map[string]bool)
+)
+
+// Lock acquires the advisory lock of path, a path.lock file created
+// exclusively, and returns a function that releases it. Hold it across a
+// read-modify-write of path so that concurrent commands do not lose updates;
+// WriteFile does not lock again while the lock is held
+func Lock(path string) (func(), error) {
+	path = resolve(path)
+	if err := acquire(path); err != nil {
+		return nil, err
+	}
+
+	heldMu.Lock()
+	held[path] = true
+	heldMu.Unlock()
+
+	return func() {
+		heldMu.Lock()
+		delete(held, path)
+		heldMu.Unlock()
+		release(path)
+	}, nil
+}
+
+// lockIfNotHeld locks path unless this process holds its lock already
+func lockIfNotHeld(path string) (func(), error) {
+	heldMu.Lock()
+	isHeld := held[path]
+	heldMu.Unlock()
+	if isHeld {
+		return func() {}, nil
+	}
+
+	if err := acquire(path); err != nil {
+		return nil, err
+	}
+	return func() { release(path) }, nil
+}
+
+func acquire(path string) error {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		if data, stale := staleLock(lockPath); stale {
+			takeOver(lockPath, data)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// staleLock reports whether the lock file at lockPath is left over from a
+// process that no longer runs, and returns the content it judged by. Locks of
+// running processes are never stale, however long they are held
+func staleLock(lockPath string) ([]byte, bool) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, false
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+		return data, !processAlive(pid)
+	}
+	info, err := os.Stat(lockPath)
+	return data, err == nil && time.Since(info.ModTime()) > staleLockAge
+}
+
+// takeOver removes the stale lock file at lockPath, whose content was data.
+// The file is first renamed to a name unique to this process, so that of
+// several processes taking over the same lock only one removes it. If the
+// renamed file is not the stale one, another process has taken over and
+// locked in the meantime, and its lock is put back.
+func takeOver(lockPath string, data []byte) {
+	stalePath := fmt.Sprintf("%s.stale.%d", lockPath, os.Getpid())
+	if err := os.Rename(lockPath, stalePath); err != nil {
+		return
+	}
+	if current, err := os.ReadFile(stalePath); err == nil && !bytes.Equal(current, data) {
+		os.Link(stalePath, lockPath)
+	}
+	os.Remove(stalePath)
+}
+
+// processAlive reports whether a process with the given PID runs
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Signal 0 only checks for existence; Windows finds running processes
+	// only and does not support it
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM) || runtime.GOOS == "windows"
+}
+
+func release(path string) {
+	os.Remove(path + ".lock")
+}
+
+// resolve follows symlinks, so that files are written through them instead
+// of replacing them and a file has a single lock
+func resolve(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// WriteFile writes data to a temporary file next to path and renames it over
+// path while holding its lock, so readers never see a partial file. An
+// existing file keeps its mode and, when BackupDir is set, its previous
+// content is backed up first. perm is used for new files.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return writeFile(path, data, perm, true)
+}
+
+// WriteFileNoBackup is WriteFile without a backup of the replaced content,
+// for state files that are not worth restoring
+func WriteFileNoBackup(path string, data []byte, perm os.FileMode) error {
+	return writeFile(path, data, perm, false)
+}
+
+func writeFile(path string, data []byte, perm os.FileMode, backup bool) error {
+	path = resolve(path)
+
+	unlock, err := lockIfNotHeld(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		if backup {
+			if err := backupFile(path, data, perm); err != nil {
+				return err
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// backupFile saves the current content of path unless it equals data
+func backupFile(path string, data []byte, perm os.FileMode) error {
+	if BackupDir == "" {
+		return nil
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(current, data) {
+		return nil
+	}
+
+	if _, err := saveBackup(path, current, perm); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+
+	t.Run("creates file with perm", func(t *testing.T) {
+		if err := WriteFile(path, []byte("one"), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "one" {
+			t.Errorf("content = %q, want %q", data, "one")
+		}
+	})
+
+	t.Run("preserves existing mode", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not preserved on windows")
+		}
+		if err := os.Chmod(path, 0640); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteFile(path, []byte("two"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0640))
+		}
+	})
+
+	t.Run("writes through symlinks", func(t *testing.T) {
+		link := filepath.Join(dir, "link.json")
+		if err := os.Symlink(path, link); err != nil {
+			t.Skip("symlinks not supported")
+		}
+		if err := WriteFile(link, []byte("three"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+			t.Error("WriteFile() replaced the symlink")
+		}
+		if data, _ := os.ReadFile(path); string(data) != "three" {
+			t.Errorf("target content = %q, want %q", data, "three")
+		}
+	})
+
+	t.Run("leaves no temporary files", func(t *testing.T) {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if name := entry.Name(); name != "settings.json" && name != "link.json" {
+				t.Errorf("unexpected file %s", name)
+			}
+		}
+	})
+}
+
+func TestWriteFileConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ccs.json")
+	payloads := []string{`{"a":1}`, `{"b":22}`, `{"c":333}`, `{"d":4444}`}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(payload string) {
+			defer wg.Done()
+			if err := WriteFile(path, []byte(payload), 0644); err != nil {
+				t.Errorf("WriteFile() error = %v", err)
+			}
+		}(payloads[i%len(payloads)])
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	valid := false
+	for _, payload := range payloads {
+		if string(data) == payload {
+			valid = true
+		}
+	}
+	if !valid {
+		t.Errorf("content = %q, want one complete payload", data)
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ccs.json")
+
+	originalTimeout := lockTimeout
+	lockTimeout = 100 * time.Millisecond
+	defer func() { lockTimeout = originalTimeout }()
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if _, err := Lock(path); err == nil {
+		t.Error("Lock() on a held lock expected error")
+	}
+	unlock()
+
+	t.Run("writes while holding the lock", func(t *testing.T) {
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		if err := WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Errorf("WriteFile() while holding the lock error = %v", err)
+		}
+		unlock()
+
+		if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+			t.Errorf("lock file left behind: %v", err)
+		}
+	})
+
+	t.Run("stale lock is removed", func(t *testing.T) {
+		// A process that has exited leaves its PID behind
+		exited := exec.Command(os.Args[0], "-test.run=^$")
+		if err := exited.Run(); err != nil {
+			t.Fatal(err)
+		}
+		lockPath := path + ".lock"
+		if err := os.WriteFile(lockPath, []byte(fmt.Sprintf("%d\n", exited.Process.Pid)), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		unlock()
+		if matches, _ := filepath.Glob(lockPath + ".stale.*"); len(matches) > 0 {
+			t.Errorf("stale lock left behind: %v", matches)
+		}
+	})
+
+	t.Run("lock of a running process is kept however old", func(t *testing.T) {
+		lockPath := path + ".lock"
+		if err := os.WriteFile(lockPath, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(lockPath)
+		old := time.Now().Add(-time.Hour)
+		os.Chtimes(lockPath, old, old)
+
+		if _, err := Lock(path); err == nil {
+			t.Error("Lock() took over the lock of a running process")
+		}
+	})
+
+	t.Run("old lock without a PID is removed", func(t *testing.T) {
+		lockPath := path + ".lock"
+		if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Lock(path); err == nil {
+			t.Fatal("Lock() took over a fresh lock without a PID")
+		}
+
+		old := time.Now().Add(-time.Hour)
+		os.Chtimes(lockPath, old, old)
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		unlock()
+	})
+}
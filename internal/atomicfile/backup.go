@@ -0,0 +1,166 @@
+package atomicfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxBackupsPerFile is the number of backups kept for each file; older
+// backups are removed as new ones are made
+const MaxBackupsPerFile = 10
+
+// BackupDir is where WriteFile stores backups. Backups are disabled when empty
+var BackupDir string
+
+// Backup is a previous version of a file
+type Backup struct {
+	ID   string      `json:"id"`
+	Path string      `json:"path"`
+	Time time.Time   `json:"time"`
+	Mode os.FileMode `json:"mode"`
+	Data []byte      `json:"data"`
+}
+
+// ListBackups returns the backups in dir, newest first
+func ListBackups(dir string) ([]*Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*Backup
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		b, err := LoadBackup(dir, id)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// LoadBackup reads the backup with the given ID from dir
+func LoadBackup(dir, id string) (*Backup, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid backup id '%s'", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("backup '%s' not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Backup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse backup '%s': %w", id, err)
+	}
+	b.ID = id
+	return &b, nil
+}
+
+// Restore writes the backed up content back to its original path. The
+// content it replaces is backed up in turn, so a restore can be undone
+func (b *Backup) Restore() error {
+	return WriteFile(b.Path, b.Data, b.Mode)
+}
+
+// saveBackup stores data as a new backup of path and prunes old backups
+func saveBackup(path string, data []byte, mode os.FileMode) (*Backup, error) {
+	if err := os.MkdirAll(BackupDir, 0700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	b := &Backup{
+		ID:   now.UTC().Format("20060102-150405.000000") + "-" + name,
+		Path: path,
+		Time: now,
+		Mode: mode,
+		Data: data,
+	}
+
+	encoded, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(BackupDir, b.ID+".json"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(encoded); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return b, pruneBackups(path)
+}
+
+// RemoveBackups removes every backup of path, e.g. after secrets were moved
+// out of it. It returns the number of backups removed
+func RemoveBackups(path string) (int, error) {
+	if BackupDir == "" {
+		return 0, nil
+	}
+	backups, err := ListBackups(BackupDir)
+	if err != nil {
+		return 0, err
+	}
+
+	path = resolve(path)
+	removed := 0
+	for _, b := range backups {
+		if b.Path != path {
+			continue
+		}
+		if err := os.Remove(filepath.Join(BackupDir, b.ID+".json")); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// pruneBackups removes all but the newest MaxBackupsPerFile backups of path
+func pruneBackups(path string) error {
+	backups, err := ListBackups(BackupDir)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, b := range backups {
+		if b.Path != path {
+			continue
+		}
+		kept++
+		if kept > MaxBackupsPerFile {
+			if err := os.Remove(filepath.Join(BackupDir, b.ID+".json")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
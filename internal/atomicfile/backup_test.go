@@ -0,0 +1,105 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackups(t *testing.T) {
+	dir := t.TempDir()
+	BackupDir = filepath.Join(dir, "backups")
+	defer func() { BackupDir = "" }()
+
+	path := filepath.Join(dir, "settings.json")
+	other := filepath.Join(dir, "ccs.json")
+
+	if err := WriteFile(path, []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := ListBackups(BackupDir); len(backups) != 0 {
+		t.Errorf("creating a file made %d backups, want 0", len(backups))
+	}
+
+	if err := WriteFile(path, []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := ListBackups(BackupDir); len(backups) != 0 {
+		t.Errorf("unchanged write made %d backups, want 0", len(backups))
+	}
+
+	if err := WriteFile(other, []byte("profiles"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(other, []byte("profiles v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= MaxBackupsPerFile+2; i++ {
+		if err := WriteFile(path, []byte("v"+string(rune('0'+i))), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := ListBackups(BackupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, b := range backups {
+		counts[b.Path]++
+	}
+	if counts[path] != MaxBackupsPerFile || counts[other] != 1 {
+		t.Errorf("backup counts = %v, want %d for settings and 1 for profiles", counts, MaxBackupsPerFile)
+	}
+	if backups[0].Path != path || string(backups[0].Data) != "v"+string(rune('0'+MaxBackupsPerFile+1)) {
+		t.Errorf("newest backup = %s %q", backups[0].Path, backups[0].Data)
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		var profilesBackup *Backup
+		for _, b := range backups {
+			if b.Path == other {
+				profilesBackup = b
+			}
+		}
+		loaded, err := LoadBackup(BackupDir, profilesBackup.ID)
+		if err != nil {
+			t.Fatalf("LoadBackup() error = %v", err)
+		}
+		if err := loaded.Restore(); err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if data, _ := os.ReadFile(other); string(data) != "profiles" {
+			t.Errorf("restored content = %q, want %q", data, "profiles")
+		}
+
+		after, _ := ListBackups(BackupDir)
+		if len(after) != len(backups)+1 || string(after[0].Data) != "profiles v2" {
+			t.Error("Restore() should back up the content it replaces")
+		}
+	})
+
+	t.Run("invalid ids", func(t *testing.T) {
+		for _, id := range []string{"", "../settings", "missing"} {
+			if _, err := LoadBackup(BackupDir, id); err == nil {
+				t.Errorf("LoadBackup(%q) expected error", id)
+			}
+		}
+	})
+
+	t.Run("remove backups of a file", func(t *testing.T) {
+		removed, err := RemoveBackups(other)
+		if err != nil || removed != 2 {
+			t.Fatalf("RemoveBackups() = %d, %v, want 2", removed, err)
+		}
+		after, _ := ListBackups(BackupDir)
+		for _, b := range after {
+			if b.Path == other {
+				t.Errorf("backup %s of %s was not removed", b.ID, other)
+			}
+		}
+		if len(after) != MaxBackupsPerFile {
+			t.Errorf("%d backups left, want the %d of settings", len(after), MaxBackupsPerFile)
+		}
+	})
+}
@@ -2,7 +2,10 @@ package cmdutil
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
 	"github.com/huangdijia/ccswitch/internal/output"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
 	"github.com/huangdijia/ccswitch/internal/profiles"
@@ -18,6 +21,37 @@ func LoadProfiles(profilesPath string) (*profiles.Profiles, error) {
 	return profs, nil
 }
 
+// LockProfiles locks the profiles file for a read-modify-write and returns a
+// function that releases the lock. Load the profiles after locking them
+func LockProfiles(profilesPath string) (func(), error) {
+	if !pathutil.FileExists(profilesPath) {
+		return nil, fmt.Errorf("profiles file not found: %s", profilesPath)
+	}
+	return atomicfile.Lock(profilesPath)
+}
+
+// UpdateProfiles loads the profiles, lets update change them and saves them,
+// holding the lock of the profiles file throughout so that concurrent
+// commands do not lose each other's changes. Nothing is saved when update
+// fails. Ask for input before calling it, so that other commands do not wait
+// on the lock while the user types
+func UpdateProfiles(profilesPath string, update func(*profiles.Profiles) error) error {
+	unlock, err := LockProfiles(profilesPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	profs, err := LoadProfiles(profilesPath)
+	if err != nil {
+		return err
+	}
+	if err := update(profs); err != nil {
+		return err
+	}
+	return profs.Save()
+}
+
 // LoadSettings loads settings from the given path with error handling
 func LoadSettings(settingsPath string) (*settings.ClaudeSettings, error) {
 	currentSettings, err := settings.New(settingsPath)
@@ -27,6 +61,19 @@ func LoadSettings(settingsPath string) (*settings.ClaudeSettings, error) {
 	return currentSettings, nil
 }
 
+// LockSettings locks a settings file for a read-modify-write, creating its
+// directory when needed, and returns a function that releases the lock
+func LockSettings(settingsPath string) (func(), error) {
+	path, err := pathutil.ExpandHome(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := pathutil.EnsureDir(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return atomicfile.Lock(path)
+}
+
 // ResolveSettingsPath resolves the settings path from profiles or uses default
 func ResolveSettingsPath(settingsPath, profilesPath string) string {
 	if settingsPath != "" {
@@ -46,8 +93,80 @@ func ResolveSettingsPath(settingsPath, profilesPath string) string {
 	return pathutil.DefaultSettingsPath()
 }
 
-// ValidateProfile validates that a profile exists and returns error with suggestions if not
+// ResolveScopedSettingsPath resolves the settings file for the given scope.
+// The user scope honours the --settings flag and the profiles configuration,
+// while project and local scopes point into the current git worktree.
+func ResolveScopedSettingsPath(scope settings.Scope, settingsPath, profilesPath string) (string, error) {
+	if scope == settings.ScopeUser {
+		return ResolveSettingsPath(settingsPath, profilesPath), nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	root, err := pathutil.FindProjectRoot(cwd)
+	if err != nil {
+		return "", fmt.Errorf("scope '%s' requires a git worktree: %w", scope, err)
+	}
+
+	return settings.ProjectPath(scope, root), nil
+}
+
+// ScopedSettings is a settings file together with the scope it belongs to
+type ScopedSettings struct {
+	Scope    settings.Scope
+	Path     string
+	Settings *settings.ClaudeSettings
+}
+
+// EffectiveSettings returns the highest-precedence settings file that
+// configures a model or environment. Files that do not exist are skipped and
+// never created. The user scope is returned when no file configures anything.
+func EffectiveSettings(settingsPath, profilesPath string) (*ScopedSettings, error) {
+	for _, scope := range settings.Scopes {
+		path, err := ResolveScopedSettingsPath(scope, settingsPath, profilesPath)
+		if err != nil {
+			continue
+		}
+		expanded, err := pathutil.ExpandHome(path)
+		if err != nil || !pathutil.FileExists(expanded) {
+			continue
+		}
+
+		s, err := LoadSettings(path)
+		if err != nil {
+			return nil, err
+		}
+		if scope == settings.ScopeUser || s.Model != "" || len(s.Env) > 0 {
+			return &ScopedSettings{Scope: scope, Path: path, Settings: s}, nil
+		}
+	}
+
+	path := ResolveSettingsPath(settingsPath, profilesPath)
+	s, err := LoadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ScopedSettings{Scope: settings.ScopeUser, Path: path, Settings: s}, nil
+}
+
+// ValidateProfile validates that a profile exists and resolves, and returns
+// error with suggestions if it does not exist
 func ValidateProfile(profs *profiles.Profiles, profileName string) error {
+	if err := RequireProfile(profs, profileName); err != nil {
+		return err
+	}
+	if _, err := profs.Resolve(profileName); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RequireProfile checks that a profile exists and returns error with
+// suggestions if not. Unlike ValidateProfile it does not resolve the profile,
+// so that broken profiles can still be edited, renamed or removed
+func RequireProfile(profs *profiles.Profiles, profileName string) error {
 	if !profs.Has(profileName) {
 		output.Error("Profile '%s' not found.", profileName)
 		fmt.Println("Available profiles:")
@@ -1,9 +1,16 @@
 package cmdutil
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/settings"
 )
 
 func TestResolveSettingsPath(t *testing.T) {
@@ -79,6 +86,60 @@ func TestLoadProfiles(t *testing.T) {
 	})
 }
 
+func TestUpdateProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	if err := os.WriteFile(profilesPath, []byte(`{"profiles": {"test": {}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("concurrent updates are all kept", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+					return profs.SetEnv("test", map[string]string{fmt.Sprintf("VAR_%d", i): "1"})
+				})
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("UpdateProfiles() error = %v", err)
+			}
+		}
+
+		profs, err := LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(profs.Get("test")); got != 10 {
+			t.Errorf("len(env) = %d, want 10: %v", got, profs.Get("test"))
+		}
+	})
+
+	t.Run("failed update is not saved", func(t *testing.T) {
+		err := UpdateProfiles(profilesPath, func(profs *profiles.Profiles) error {
+			profs.Delete("test")
+			return errors.New("failed")
+		})
+		if err == nil {
+			t.Fatal("UpdateProfiles() expected error")
+		}
+		profs, err := LoadProfiles(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !profs.Has("test") {
+			t.Error("UpdateProfiles() saved a failed update")
+		}
+	})
+}
+
 func TestLoadSettings(t *testing.T) {
 	tmpDir := t.TempDir()
 	settingsPath := filepath.Join(tmpDir, "settings.json")
@@ -134,3 +195,85 @@ func TestValidateProfile(t *testing.T) {
 		}
 	})
 }
+
+func chdirProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+	// Resolve symlinks (e.g. /tmp on macOS) so paths compare equal.
+	root, _ = os.Getwd()
+	return root
+}
+
+func TestResolveScopedSettingsPath(t *testing.T) {
+	root := chdirProject(t)
+
+	tests := []struct {
+		scope settings.Scope
+		want  string
+	}{
+		{settings.ScopeUser, "/explicit/settings.json"},
+		{settings.ScopeProject, filepath.Join(root, ".claude", "settings.json")},
+		{settings.ScopeLocal, filepath.Join(root, ".claude", "settings.local.json")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scope), func(t *testing.T) {
+			got, err := ResolveScopedSettingsPath(tt.scope, "/explicit/settings.json", "")
+			if err != nil {
+				t.Fatalf("ResolveScopedSettingsPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveScopedSettingsPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveSettings(t *testing.T) {
+	root := chdirProject(t)
+	userPath := filepath.Join(t.TempDir(), "settings.json")
+
+	t.Run("falls back to user scope", func(t *testing.T) {
+		effective, err := EffectiveSettings(userPath, "")
+		if err != nil {
+			t.Fatalf("EffectiveSettings() error = %v", err)
+		}
+		if effective.Scope != settings.ScopeUser {
+			t.Errorf("EffectiveSettings().Scope = %v, want %v", effective.Scope, settings.ScopeUser)
+		}
+		if pathutil.FileExists(filepath.Join(root, ".claude")) {
+			t.Error("EffectiveSettings() should not create project settings files")
+		}
+	})
+
+	t.Run("local scope wins when configured", func(t *testing.T) {
+		localPath := settings.ProjectPath(settings.ScopeLocal, root)
+		s, err := LoadSettings(localPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Env["ANTHROPIC_MODEL"] = "local-model"
+		if err := s.Write(); err != nil {
+			t.Fatal(err)
+		}
+
+		effective, err := EffectiveSettings(userPath, "")
+		if err != nil {
+			t.Fatalf("EffectiveSettings() error = %v", err)
+		}
+		if effective.Scope != settings.ScopeLocal {
+			t.Errorf("EffectiveSettings().Scope = %v, want %v", effective.Scope, settings.ScopeLocal)
+		}
+		if effective.Path != localPath {
+			t.Errorf("EffectiveSettings().Path = %v, want %v", effective.Path, localPath)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"golang.org/x/term"
+)
+
+// VaultPassphraseEnv is the environment variable read for the vault passphrase
+const VaultPassphraseEnv = "CCSWITCH_VAULT_PASSPHRASE"
+
+// VaultPath returns the vault file stored next to the profiles configuration
+func VaultPath(profilesPath string) string {
+	return filepath.Join(filepath.Dir(profilesPath), "vault.json")
+}
+
+// OpenSecretStore opens the secret store configured for the profiles
+func OpenSecretStore(profs *profiles.Profiles) (secrets.Store, error) {
+	return OpenSecretBackend(profs.Data.SecretsBackend, profs.Path)
+}
+
+// OpenSecretBackend opens the named secret store backend
+func OpenSecretBackend(backend, profilesPath string) (secrets.Store, error) {
+	switch backend {
+	case secrets.BackendVault:
+		passphrase, err := vaultPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		return secrets.OpenVault(VaultPath(profilesPath), passphrase)
+	case secrets.BackendSecretService:
+		return secrets.NewSecretService()
+	case "":
+		return nil, fmt.Errorf("no secrets backend configured (set \"secretsBackend\" in %s)", profilesPath)
+	}
+	return nil, fmt.Errorf("unknown secrets backend '%s'", backend)
+}
+
+// vaultPassphrase reads the vault passphrase from the environment or prompts for it
+func vaultPassphrase() (string, error) {
+	if passphrase := os.Getenv(VaultPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("vault passphrase required: set %s or run in a terminal", VaultPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Vault passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// ResolveSecrets replaces secret references in env with their stored values.
+// The secret store is only opened when env actually contains references.
+func ResolveSecrets(profs *profiles.Profiles, env map[string]string) (map[string]string, error) {
+	return NewSecretResolver(profs).Resolve(env)
+}
+
+// SecretResolver resolves secret references for several profiles, opening
+// the secret store at most once
+type SecretResolver struct {
+	profs *profiles.Profiles
+	store secrets.Store
+}
+
+// NewSecretResolver returns a resolver for the secrets of profs
+func NewSecretResolver(profs *profiles.Profiles) *SecretResolver {
+	return &SecretResolver{profs: profs}
+}
+
+// Resolve replaces secret references in env with their stored values
+func (r *SecretResolver) Resolve(env map[string]string) (map[string]string, error) {
+	if !secrets.HasRefs(env) {
+		return env, nil
+	}
+
+	if r.store == nil {
+		store, err := OpenSecretStore(r.profs)
+		if err != nil {
+			return nil, err
+		}
+		r.store = store
+	}
+	return secrets.Resolve(r.store, env)
+}
+
+// StoreSecrets moves the secret values of a profile into the configured store
+// and replaces them in env with references. It does nothing when no secrets
+// backend is configured or env holds no plaintext secrets.
+func StoreSecrets(profs *profiles.Profiles, profileName string, env map[string]string) error {
+	if profs.Data.SecretsBackend == "" || !HasPlaintextSecrets(env) {
+		return nil
+	}
+
+	store, err := OpenSecretStore(profs)
+	if err != nil {
+		return err
+	}
+	_, err = MoveSecrets(store, profileName, env)
+	return err
+}
+
+// MoveSecrets stores the plaintext secret values of env in store, replaces
+// them with references and returns how many values were moved
+func MoveSecrets(store secrets.Store, profileName string, env map[string]string) (int, error) {
+	keys := plaintextSecretKeys(env)
+	for _, key := range keys {
+		name := secrets.Name(profileName, key)
+		if err := store.Set(name, env[key]); err != nil {
+			return 0, fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		env[key] = secrets.Ref(name)
+	}
+	return len(keys), nil
+}
+
+// HasPlaintextSecrets reports whether env holds a real token that is not a
+// secret reference
+func HasPlaintextSecrets(env map[string]string) bool {
+	return len(plaintextSecretKeys(env)) > 0
+}
+
+// plaintextSecretKeys returns the secret keys in env that hold a real
+// plaintext value, skipping references and empty or placeholder tokens
+func plaintextSecretKeys(env map[string]string) []string {
+	var keys []string
+	for _, key := range secrets.Keys {
+		value, ok := env[key]
+		if ok && !secrets.IsRef(value) && !output.IsEmptyToken(value) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// OpenSecretStoreFor opens the secret store when a profile owns secrets in
+// it, returning nil when keys is empty so the store is not opened needlessly
+func OpenSecretStoreFor(profs *profiles.Profiles, keys []string) (secrets.Store, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return OpenSecretStore(profs)
+}
+
+// CopySecrets copies the stored secrets of keys from profile src to profile
+// dst. Secrets missing from the store are skipped.
+func CopySecrets(store secrets.Store, src, dst string, keys []string) error {
+	for _, key := range keys {
+		value, err := store.Get(secrets.Name(src, key))
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", key, err)
+		}
+		if err := store.Set(secrets.Name(dst, key), value); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// DeleteSecrets deletes the stored secrets of keys owned by profile. Secrets
+// already missing from the store are ignored.
+func DeleteSecrets(store secrets.Store, profile string, keys []string) error {
+	for _, key := range keys {
+		err := store.Delete(secrets.Name(profile, key))
+		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package history records profile switches so previous profiles can be
+// listed and restored
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+)
+
+// MaxEntries is the number of entries kept in the history file
+const MaxEntries = 500
+
+// Entry is one profile switch. Profile is empty for a reset
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Profile      string    `json:"profile,omitempty"`
+	SettingsPath string    `json:"settingsPath"`
+	Scope        string    `json:"scope"`
+}
+
+// Path returns the history file stored next to the profiles file
+func Path(profilesPath string) string {
+	return filepath.Join(filepath.Dir(profilesPath), "history.jsonl")
+}
+
+// Load reads all entries, oldest first. A missing file has no entries
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		// Skip lines that cannot be parsed, e.g. from an interrupted write
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Append adds an entry to the history file, dropping the oldest entries
+// once there are more than MaxEntries
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := Load(path)
+	if err != nil {
+		return err
+	}
+	entry.SettingsPath = normalize(entry.SettingsPath)
+	entries = append(entries, entry)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return write(path, entries)
+}
+
+// RenameProfile points the entries of profile oldName at newName, so the
+// history keeps following a renamed profile
+func RenameProfile(path, oldName, newName string) error {
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := Load(path)
+	if err != nil {
+		return err
+	}
+	renamed := false
+	for i := range entries {
+		if entries[i].Profile == oldName {
+			entries[i].Profile = newName
+			renamed = true
+		}
+	}
+	if !renamed {
+		return nil
+	}
+	return write(path, entries)
+}
+
+// write replaces the history file with entries
+func write(path string, entries []Entry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return atomicfile.WriteFileNoBackup(path, buf.Bytes(), 0644)
+}
+
+// ForSettings returns the entries that wrote to settingsPath, oldest first
+func ForSettings(entries []Entry, settingsPath string) []Entry {
+	settingsPath = normalize(settingsPath)
+	var filtered []Entry
+	for _, e := range entries {
+		if e.SettingsPath == settingsPath {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// normalize makes settings paths comparable across invocations
+func normalize(path string) string {
+	if expanded, err := pathutil.ExpandHome(path); err == nil {
+		path = expanded
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// Current returns the latest entry of entries, or nil when there is none
+func Current(entries []Entry) *Entry {
+	if len(entries) == 0 {
+		return nil
+	}
+	return &entries[len(entries)-1]
+}
+
+// Previous returns the most recent profile that differs from the current
+// one, like "cd -". It returns an empty string when there is none
+func Previous(entries []Entry) string {
+	current := Current(entries)
+	if current == nil {
+		return ""
+	}
+	for i := len(entries) - 2; i >= 0; i-- {
+		if p := entries[i].Profile; p != "" && p != current.Profile {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "ccs.json"))
+
+	entries, err := Load(path)
+	if err != nil || entries != nil {
+		t.Fatalf("Load() without file = %v, %v, want nil", entries, err)
+	}
+
+	for _, profile := range []string{"a", "b", ""} {
+		if err := Append(path, Entry{Time: time.Now(), Profile: profile, SettingsPath: "settings.json", Scope: "user"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	entries, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Profile != "a" || entries[2].Profile != "" {
+		t.Errorf("Load() = %v, want a, b and a reset", entries)
+	}
+	if !filepath.IsAbs(entries[0].SettingsPath) {
+		t.Errorf("SettingsPath = %v, want an absolute path", entries[0].SettingsPath)
+	}
+}
+
+func TestAppendTrims(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "ccs.json"))
+	for i := 0; i < MaxEntries+5; i++ {
+		if err := Append(path, Entry{Profile: "p", SettingsPath: "/s"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := Load(path)
+	if len(entries) != MaxEntries {
+		t.Errorf("Load() returned %d entries, want %d", len(entries), MaxEntries)
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "ccs.json"))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Append(path, Entry{Time: time.Now(), Profile: "glm"}); err != nil {
+				t.Errorf("Append() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if entries, _ := Load(path); len(entries) != 10 {
+		t.Errorf("Load() = %d entries, want 10", len(entries))
+	}
+}
+
+func TestLoadSkipsCorruptLines(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "ccs.json"))
+	data := `{"profile":"a","settingsPath":"/s"}` + "\n" + `{"profile":` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Load(path)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Load() = %v, %v, want one entry", entries, err)
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	entry := func(profile, settingsPath string) Entry {
+		return Entry{Profile: profile, SettingsPath: settingsPath}
+	}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    string
+	}{
+		{"empty", nil, ""},
+		{"single", []Entry{entry("a", "/s")}, ""},
+		{"toggle", []Entry{entry("a", "/s"), entry("b", "/s")}, "a"},
+		{"repeated current", []Entry{entry("a", "/s"), entry("b", "/s"), entry("b", "/s")}, "a"},
+		{"after reset", []Entry{entry("a", "/s"), entry("", "/s")}, "a"},
+		{"other settings ignored", []Entry{entry("a", "/s"), entry("c", "/other"), entry("b", "/s")}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Previous(ForSettings(tt.entries, "/s")); got != tt.want {
+				t.Errorf("Previous() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
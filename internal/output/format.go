@@ -0,0 +1,215 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Output formats accepted by --output
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatTemplate = "template"
+)
+
+// Format is how a command prints its result
+type Format struct {
+	Name string
+	// Template is set for the template format
+	Template *template.Template
+}
+
+// ParseFormat parses an --output value: table, json, yaml or
+// template=<Go template>
+func ParseFormat(value string) (*Format, error) {
+	name, text, hasText := strings.Cut(value, "=")
+	switch name {
+	case "", FormatTable:
+		if !hasText {
+			return &Format{Name: FormatTable}, nil
+		}
+	case FormatJSON, FormatYAML:
+		if !hasText {
+			return &Format{Name: name}, nil
+		}
+	case FormatTemplate:
+		if text == "" {
+			return nil, fmt.Errorf("the template format needs a template, such as template='{{.Name}}'")
+		}
+		tmpl, err := template.New("output").Funcs(template.FuncMap{"json": templateJSON}).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return &Format{Name: FormatTemplate, Template: tmpl}, nil
+	}
+	return nil, fmt.Errorf("unknown output format '%s': expected table, json, yaml or template=<template>", value)
+}
+
+// Structured reports whether the result is printed as data instead of text
+func (f *Format) Structured() bool {
+	return f.Name != FormatTable
+}
+
+// Write prints v in a structured format. Templates are executed on v itself,
+// so they use the Go field names of the result types
+func Write(w io.Writer, f *Format, v any) error {
+	switch f.Name {
+	case FormatJSON:
+		data, err := json.MarshalIndent(v, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatYAML:
+		data, err := MarshalYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case FormatTemplate:
+		var buf bytes.Buffer
+		if err := f.Template.Execute(&buf, v); err != nil {
+			return err
+		}
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	return fmt.Errorf("%s is not a structured output format", f.Name)
+}
+
+func templateJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// MarshalYAML encodes v as YAML. v is first encoded as JSON, so JSON tags
+// and marshalers apply; object keys are sorted
+func MarshalYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if isYAMLBlock(value) {
+		writeYAMLBlock(&buf, value, "")
+	} else {
+		buf.WriteString(yamlScalar(value) + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// isYAMLBlock reports whether v is a non-empty object or array, which is
+// written over several lines
+func isYAMLBlock(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+func writeYAMLBlock(buf *bytes.Buffer, v any, indent string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			buf.WriteString(indent + yamlString(key) + ":")
+			writeYAMLValue(buf, v[key], indent)
+		}
+	case []any:
+		for _, item := range v {
+			if !isYAMLBlock(item) {
+				buf.WriteString(indent + "- " + yamlScalar(item) + "\n")
+				continue
+			}
+			// The first line of the nested block follows the dash
+			var nested bytes.Buffer
+			writeYAMLBlock(&nested, item, indent+"  ")
+			buf.WriteString(indent + "- ")
+			buf.Write(nested.Bytes()[len(indent)+2:])
+		}
+	}
+}
+
+func writeYAMLValue(buf *bytes.Buffer, v any, indent string) {
+	if !isYAMLBlock(v) {
+		buf.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	buf.WriteString("\n")
+	writeYAMLBlock(buf, v, indent+"  ")
+}
+
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	case map[string]any:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlString returns s unquoted when YAML reads it back as the same string,
+// and as a double-quoted string otherwise
+func yamlString(s string) string {
+	if yamlNeedsQuotes(s) {
+		// A JSON string is a valid YAML double-quoted string
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+	return s
+}
+
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`0123456789.+") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", FormatTable, false},
+		{"table", FormatTable, false},
+		{"json", FormatJSON, false},
+		{"yaml", FormatYAML, false},
+		{"template={{.Name}}", FormatTemplate, false},
+		{"template=", "", true},
+		{"template={{.Name", "", true},
+		{"json=x", "", true},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		f, err := ParseFormat(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && f.Name != tt.want {
+			t.Errorf("ParseFormat(%q) = %s, want %s", tt.value, f.Name, tt.want)
+		}
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	v := map[string]any{
+		"name":    "glm",
+		"default": true,
+		"count":   3,
+		"empty":   "",
+		"url":     "https://api.test.com",
+		"quoted":  "yes",
+		"version": "1.0",
+		"note":    "a: b",
+		"env":     map[string]string{"B": "2", "A": "x\ny"},
+		"none":    map[string]string{},
+		"rules": []any{
+			map[string]string{"pattern": "*opus*", "model": "glm-4.6"},
+			"plain",
+		},
+	}
+
+	got, err := MarshalYAML(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `count: 3
+default: true
+empty: ""
+env:
+  A: "x\ny"
+  B: "2"
+name: glm
+none: {}
+note: "a: b"
+quoted: "yes"
+rules:
+  - model: glm-4.6
+    pattern: "*opus*"
+  - plain
+url: https://api.test.com
+version: "1.0"
+`
+	if string(got) != want {
+		t.Errorf("MarshalYAML() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, _ := MarshalYAML("text"); string(got) != "text\n" {
+		t.Errorf("MarshalYAML(scalar) = %q", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	result := &ProfileList{Profiles: []ProfileSummary{{Name: "a", Default: true}, {Name: "b"}}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", "{\n    \"profiles\": [\n        {\n            \"name\": \"a\",\n            \"default\": true,\n            \"active\": false,\n            \"drifted\": false\n        },\n        {\n            \"name\": \"b\",\n            \"default\": false,\n            \"active\": false,\n            \"drifted\": false\n        }\n    ],\n    \"settingsPath\": \"\"\n}\n"},
+		{"template={{range .Profiles}}{{.Name}} {{end}}", "a b \n"},
+		{"template={{json (index .Profiles 0).Name}}", "\"a\"\n"},
+	}
+
+	for _, tt := range tests {
+		f, err := ParseFormat(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Write(&buf, f, result); err != nil {
+			t.Fatalf("Write(%s) error = %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Write(%s) = %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	table, _ := ParseFormat("table")
+	if err := Write(&bytes.Buffer{}, table, result); err == nil {
+		t.Error("Write(table) expected error")
+	}
+}
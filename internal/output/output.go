@@ -8,18 +8,26 @@ import (
 // Token prefixes that should not be masked when empty or just the prefix
 var emptyTokenPrefixes = []string{"sk-", "ms-", "sk-kimi-"}
 
-// MaskSensitiveValue masks sensitive information in a string value
-// Used for API keys, tokens, and other sensitive data
-func MaskSensitiveValue(value string) string {
-	// Check if value is empty or just a prefix
+// IsEmptyToken reports whether a token is empty or only a placeholder prefix such as "sk-"
+func IsEmptyToken(value string) bool {
 	if value == "" {
-		return "(not set)"
+		return true
 	}
 	for _, prefix := range emptyTokenPrefixes {
 		if value == prefix {
-			return "(not set)"
+			return true
 		}
 	}
+	return false
+}
+
+// MaskSensitiveValue masks sensitive information in a string value
+// Used for API keys, tokens, and other sensitive data
+func MaskSensitiveValue(value string) string {
+	// Check if value is empty or just a prefix
+	if IsEmptyToken(value) {
+		return "(not set)"
+	}
 
 	if len(value) <= 8 {
 		return strings.Repeat("*", len(value))
@@ -102,3 +102,16 @@ func TestIsSensitiveKey(t *testing.T) {
 		})
 	}
 }
+
+func TestIsEmptyToken(t *testing.T) {
+	for _, value := range []string{"", "sk-", "ms-", "sk-kimi-"} {
+		if !IsEmptyToken(value) {
+			t.Errorf("IsEmptyToken(%q) = false, want true", value)
+		}
+	}
+	for _, value := range []string{"sk-abc", "token"} {
+		if IsEmptyToken(value) {
+			t.Errorf("IsEmptyToken(%q) = true, want false", value)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package output
+
+import "time"
+
+// ProfileList is the result of the list command
+type ProfileList struct {
+	Profiles []ProfileSummary `json:"profiles"`
+	Default  string           `json:"default,omitempty"`
+	// SettingsPath is the settings file the active profile was detected in
+	SettingsPath string `json:"settingsPath"`
+	// Active is the profile matching the settings, empty for none
+	Active string `json:"active,omitempty"`
+	// Drift lists how the settings differ from the active profile
+	Drift []Difference `json:"drift,omitempty"`
+	// Custom is set when the settings configure an environment that does
+	// not match any profile
+	Custom bool `json:"custom,omitempty"`
+}
+
+// ProfileSummary is one row of the list command
+type ProfileSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Default     bool   `json:"default"`
+	Active      bool   `json:"active"`
+	Drifted     bool   `json:"drifted"`
+	// LastUsed is when the profile was last switched to, nil if never
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// Profile is the result of the show command for a profile
+type Profile struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Default     bool     `json:"default"`
+	Extends     []string `json:"extends,omitempty"`
+	// Env is the resolved environment; secrets are masked unless revealed
+	Env map[string]string `json:"env"`
+	// Sources maps each inherited key of Env to the profile it comes from
+	Sources      map[string]string `json:"sources,omitempty"`
+	ModelMap     []ModelRule       `json:"modelMap,omitempty"`
+	Settings     map[string]any    `json:"settings,omitempty"`
+	TokenCommand string            `json:"tokenCommand,omitempty"`
+}
+
+// ModelRule is a model map rule in the order it is matched
+type ModelRule struct {
+	Pattern string `json:"pattern"`
+	Model   string `json:"model"`
+	Source  string `json:"source"`
+}
+
+// CurrentSettings is the result of the show command for the current settings
+type CurrentSettings struct {
+	SettingsPath string `json:"settingsPath"`
+	Scope        string `json:"scope"`
+	// Active is the profile matching the settings, empty for none
+	Active string       `json:"active,omitempty"`
+	Drift  []Difference `json:"drift,omitempty"`
+	// ShellProfile is the profile activated in the current shell
+	ShellProfile string `json:"shellProfile,omitempty"`
+	// PinnedProfile is the profile pinned to the current directory
+	PinnedProfile string `json:"pinnedProfile,omitempty"`
+	Model         string `json:"model,omitempty"`
+	// Env is the settings environment; secrets are masked unless revealed
+	Env map[string]string `json:"env"`
+}
+
+// Difference is a key whose settings value differs from the active profile
+type Difference struct {
+	Key string `json:"key"`
+	// Profile is the profile's value, empty when the profile does not set it
+	Profile string `json:"profile,omitempty"`
+	// Settings is the settings value, empty when the settings do not set it
+	Settings string `json:"settings,omitempty"`
+}
@@ -0,0 +1,113 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// minColumnWidth is the narrowest a column is shrunk to, unless its content
+// is narrower
+const minColumnWidth = 6
+
+// Table is a box-drawn table whose columns fit their content
+type Table struct {
+	Headers []string
+	Rows    [][]string
+	// MaxWidth is the widest the table may be in columns; 0 means no limit.
+	// The widest columns are shrunk and their cells truncated to fit
+	MaxWidth int
+}
+
+// Render writes the table to w
+func (t *Table) Render(w io.Writer) error {
+	widths := t.columnWidths()
+
+	line := func(left, middle, right string) string {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("─", width+2)
+		}
+		return left + strings.Join(parts, middle) + right + "\n"
+	}
+	row := func(cells []string) string {
+		var b strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString("│ " + Pad(Truncate(cell, width), width) + " ")
+		}
+		return b.String() + "│\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(line("┌", "┬", "┐"))
+	b.WriteString(row(t.Headers))
+	b.WriteString(line("├", "┼", "┤"))
+	for _, cells := range t.Rows {
+		b.WriteString(row(cells))
+	}
+	b.WriteString(line("└", "┴", "┘"))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// columnWidths returns the content width of each column, shrinking the
+// widest columns one at a time until the table fits MaxWidth
+func (t *Table) columnWidths() []int {
+	widths := make([]int, len(t.Headers))
+	minimums := make([]int, len(t.Headers))
+	for i, header := range t.Headers {
+		widths[i] = DisplayWidth(header)
+		for _, cells := range t.Rows {
+			if i < len(cells) {
+				widths[i] = max(widths[i], DisplayWidth(cells[i]))
+			}
+		}
+		minimums[i] = min(widths[i], max(minColumnWidth, DisplayWidth(header)))
+	}
+	if t.MaxWidth <= 0 {
+		return widths
+	}
+
+	// Each column adds "│ " before and " " after its content, plus the
+	// closing "│"
+	total := 1
+	for _, width := range widths {
+		total += width + 3
+	}
+	for total > t.MaxWidth {
+		widest := -1
+		for i, width := range widths {
+			if width > minimums[i] && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+// TerminalWidth returns the width of the terminal w writes to, the COLUMNS
+// environment variable when w is not a terminal, or 0 if neither is known
+func TerminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"glm", 3},
+		{"智谱", 4},
+		{"GLM 智谱 AI", 11},
+		{"ｆｕｌｌ", 8},
+		{"é", 1},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.s); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a longer description", 10, "a longe..."},
+		{"智谱清言大模型", 9, "智谱清..."},
+		{"智谱清言大模型", 8, "智谱..."},
+		{"abcdef", 3, "abc"},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if DisplayWidth(got) > tt.width {
+			t.Errorf("Truncate(%q, %d) is %d columns wide", tt.s, tt.width, DisplayWidth(got))
+		}
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Profile", "Description"},
+		Rows: [][]string{
+			{"glm", "智谱"},
+			{"kimi", "Moonshot Kimi"},
+		},
+	}
+
+	var b strings.Builder
+	if err := table.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `┌─────────┬───────────────┐
+│ Profile │ Description   │
+├─────────┼───────────────┤
+│ glm     │ 智谱          │
+│ kimi    │ Moonshot Kimi │
+└─────────┴───────────────┘
+`
+	if b.String() != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestTableRenderMaxWidth(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Profile", "Description", "URL"},
+		Rows: [][]string{
+			{"glm", "智谱清言 GLM-4.6 coding plan", "https://open.bigmodel.cn/api/anthropic"},
+		},
+		MaxWidth: 50,
+	}
+
+	var b strings.Builder
+	if err := table.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if w := DisplayWidth(line); w != 50 {
+			t.Errorf("line %q is %d columns wide, want 50", line, w)
+		}
+	}
+	if !strings.Contains(b.String(), "│ glm     │") {
+		t.Errorf("narrow column was shrunk:\n%s", b.String())
+	}
+
+	// Columns are not shrunk below their minimum
+	table.MaxWidth = 10
+	b.Reset()
+	if err := table.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "│ Profile │ Description │ URL    │") {
+		t.Errorf("columns were shrunk below their headers:\n%s", b.String())
+	}
+}
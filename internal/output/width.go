@@ -0,0 +1,79 @@
+package output
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wideRanges are the ranges of runes shown two columns wide: CJK, Hangul,
+// fullwidth forms and emoji
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal columns r takes
+func RuneWidth(r rune) int {
+	if r < ' ' || r == 0x7f || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.lo && r <= wide.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// DisplayWidth returns the number of terminal columns s takes
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// Truncate shortens s to at most width columns, ending it with "..." when
+// it is cut. Runes are never split
+func Truncate(s string, width int) string {
+	if DisplayWidth(s) <= width {
+		return s
+	}
+	ellipsis := "..."
+	if width <= len(ellipsis) {
+		ellipsis = ""
+	}
+
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := RuneWidth(r)
+		if used+w > width-len(ellipsis) {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + ellipsis
+}
+
+// Pad fills s with spaces up to width columns
+func Pad(s string, width int) string {
+	if n := width - DisplayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+// Package ownership records which settings keys ccswitch wrote, so that
+// switching profiles leaves keys set by the user alone
+package ownership
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+)
+
+// Record lists what ccswitch wrote into one settings file. Only env key names
+// are kept so that the profile's secrets do not end up in the state file
+type Record struct {
+	Profile string   `json:"profile"`
+	Env     []string `json:"env,omitempty"`
+	// ReplacedEnv holds the user's env values that Env overwrote, so they can
+	// be restored when switching away. They may be tokens, so the state file
+	// is only readable by its owner
+	ReplacedEnv map[string]any `json:"replacedEnv,omitempty"`
+	// Model is the model written to the settings, empty if none was
+	Model string `json:"model,omitempty"`
+	// Settings holds the values merged into the settings besides model and env
+	Settings map[string]any `json:"settings,omitempty"`
+	// Replaced holds the settings values that merging Settings replaced, so
+	// they can be restored when switching away
+	Replaced map[string]any `json:"replaced,omitempty"`
+}
+
+// Owns reports whether key was written by ccswitch
+func (r *Record) Owns(key string) bool {
+	if r == nil {
+		return false
+	}
+	for _, k := range r.Env {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Path returns the ownership file stored next to the profiles file
+func Path(profilesPath string) string {
+	return filepath.Join(filepath.Dir(profilesPath), "ownership.json")
+}
+
+// Load reads the records of all settings files, keyed by settings path.
+// A missing file has no records
+func Load(path string) (map[string]*Record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]*Record), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]*Record)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// Get returns the record of settingsPath, or nil when ccswitch has not
+// written to it
+func Get(path, settingsPath string) (*Record, error) {
+	records, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return records[normalize(settingsPath)], nil
+}
+
+// Set stores the record of settingsPath; a nil record removes it
+func Set(path, settingsPath string, record *Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		delete(records, normalize(settingsPath))
+	} else {
+		records[normalize(settingsPath)] = record
+	}
+	return write(path, records)
+}
+
+// RenameProfile points the records of profile oldName at newName
+func RenameProfile(path, oldName, newName string) error {
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := Load(path)
+	if err != nil {
+		return err
+	}
+	renamed := false
+	for _, record := range records {
+		if record.Profile == oldName {
+			record.Profile = newName
+			renamed = true
+		}
+	}
+	if !renamed {
+		return nil
+	}
+	return write(path, records)
+}
+
+// write replaces the ownership file with records
+func write(path string, records map[string]*Record) error {
+	data, err := json.MarshalIndent(records, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFileNoBackup(path, data, 0600); err != nil {
+		return err
+	}
+	// Files written before replaced values were recorded keep their mode
+	return os.Chmod(path, 0600)
+}
+
+// normalize makes settings paths comparable across invocations
+func normalize(path string) string {
+	if expanded, err := pathutil.ExpandHome(path); err == nil {
+		path = expanded
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
@@ -0,0 +1,53 @@
+package ownership
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetAndGet(t *testing.T) {
+	dir := t.TempDir()
+	path := Path(filepath.Join(dir, "ccs.json"))
+	settingsPath := filepath.Join(dir, "settings.json")
+
+	record, err := Get(path, settingsPath)
+	if err != nil || record != nil {
+		t.Fatalf("Get() on a missing file = %v, %v, want nil, nil", record, err)
+	}
+
+	want := &Record{Profile: "glm", Env: []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL"}, Model: "GLM-4.6"}
+	if err := Set(path, settingsPath, want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := Set(path, filepath.Join(dir, "other.json"), &Record{Profile: "kimi"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Paths are normalized, so a relative path finds the same record
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	record, err = Get(path, "settings.json")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if record == nil || record.Profile != "glm" || record.Model != "GLM-4.6" {
+		t.Fatalf("Get() = %+v, want %+v", record, want)
+	}
+	if !record.Owns("ANTHROPIC_MODEL") || record.Owns("DISABLE_TELEMETRY") {
+		t.Error("Owns() does not match the recorded keys")
+	}
+
+	if err := Set(path, settingsPath, nil); err != nil {
+		t.Fatalf("Set(nil) error = %v", err)
+	}
+	if record, _ := Get(path, settingsPath); record != nil {
+		t.Errorf("Get() after Set(nil) = %+v, want nil", record)
+	}
+	if record, _ := Get(path, filepath.Join(dir, "other.json")); record == nil {
+		t.Error("Set(nil) removed the record of another settings file")
+	}
+}
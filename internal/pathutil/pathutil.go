@@ -1,6 +1,7 @@
 package pathutil
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -55,3 +56,24 @@ func DefaultProfilesPath() string {
 	}
 	return filepath.Join(home, ".ccswitch", "ccs.json")
 }
+
+// FindProjectRoot walks up from dir looking for the root of a git worktree.
+// A worktree root contains a .git entry, which is a directory for regular
+// checkouts and a file for linked worktrees and submodules.
+func FindProjectRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if FileExists(filepath.Join(dir, ".git")) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("not inside a git worktree")
+		}
+		dir = parent
+	}
+}
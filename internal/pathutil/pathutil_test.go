@@ -145,3 +145,52 @@ func TestDefaultProfilesPath(t *testing.T) {
 		t.Error("DefaultProfilesPath() returned empty string")
 	}
 }
+
+func TestFindProjectRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "repo")
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("from nested directory", func(t *testing.T) {
+		got, err := FindProjectRoot(nested)
+		if err != nil {
+			t.Fatalf("FindProjectRoot() error = %v", err)
+		}
+		if got != root {
+			t.Errorf("FindProjectRoot() = %v, want %v", got, root)
+		}
+	})
+
+	t.Run("linked worktree with .git file", func(t *testing.T) {
+		worktree := filepath.Join(tmpDir, "worktree")
+		if err := os.MkdirAll(worktree, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../repo/.git/worktrees/wt\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := FindProjectRoot(worktree)
+		if err != nil {
+			t.Fatalf("FindProjectRoot() error = %v", err)
+		}
+		if got != worktree {
+			t.Errorf("FindProjectRoot() = %v, want %v", got, worktree)
+		}
+	})
+
+	t.Run("outside a worktree", func(t *testing.T) {
+		outside := filepath.Join(tmpDir, "outside")
+		if err := os.MkdirAll(outside, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FindProjectRoot(outside); err == nil {
+			t.Skip("temporary directory is itself inside a git worktree")
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package pin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+)
+
+// FileNames are the pin files looked up in each directory, in order of preference
+var FileNames = []string{".ccswitch.json", ".ccswitch"}
+
+// Pin is a per-directory profile selection
+type Pin struct {
+	// Path is the file the pin was read from
+	Path string `json:"-"`
+	// Profile is the name of the pinned profile
+	Profile string `json:"profile"`
+	// Env holds overrides applied on top of the profile's environment
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Find walks up from dir and returns the first pin file found, or nil when
+// none applies. The walk stops at the enclosing git worktree root, or at the
+// home directory outside worktrees, so pin files dropped in shared parent
+// directories such as /tmp are never picked up. An invalid pin file in dir
+// itself is an error; invalid ones in parent directories are skipped with a
+// warning on stderr.
+func Find(dir string) (*Pin, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	stop := boundary(dir)
+
+	for start := dir; ; {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			p, err := Load(path)
+			if err == nil || dir == start {
+				return p, err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: ignoring invalid pin file: %v\n", err)
+		}
+		parent := filepath.Dir(dir)
+		if dir == stop || parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// boundary returns the last directory Find looks in when starting from dir:
+// the git worktree root containing dir, else the home directory when dir is
+// inside it, else dir itself
+func boundary(dir string) string {
+	if root, err := pathutil.FindProjectRoot(dir); err == nil {
+		return root
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return home
+		}
+	}
+	return dir
+}
+
+// Load reads a pin file. JSON files contain {"profile": ..., "env": {...}};
+// plain files contain the profile name on the first line, optionally
+// followed by KEY=VALUE override lines. Blank lines and # comments are ignored.
+func Load(path string) (*Pin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pin{Path: path}
+	if filepath.Ext(path) == ".json" {
+		if err := json.Unmarshal(data, p); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if err := parsePlain(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if p.Profile == "" {
+		return nil, fmt.Errorf("%s does not name a profile", path)
+	}
+	for key, value := range p.Env {
+		if err := validateOverride(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return p, nil
+}
+
+// validateOverride checks a variable a pin file overrides. Pin files come
+// with the repositories they pin and are applied on cd, so only known
+// variables that can neither redirect requests, replace credentials nor run
+// code are accepted: models, numbers and switches
+func validateOverride(key, value string) error {
+	if err := profiles.ValidateKey(key); err != nil {
+		return err
+	}
+	if !Overridable(key) {
+		return fmt.Errorf("%s cannot be overridden by a pin file (only model, number and switch variables can)", key)
+	}
+	return profiles.ValidateValue(key, value)
+}
+
+// Overridable reports whether pin files may override key
+func Overridable(key string) bool {
+	spec, ok := profiles.Schema[key]
+	if !ok {
+		return false
+	}
+	return spec.Type == profiles.KeyInt || spec.Type == profiles.KeyBool ||
+		(spec.Type == profiles.KeyString && strings.HasSuffix(key, "_MODEL"))
+}
+
+func parsePlain(data []byte, p *Pin) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if p.Profile == "" {
+			p.Profile = text
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("line %d: expected KEY=VALUE", line)
+		}
+		if p.Env == nil {
+			p.Env = make(map[string]string)
+		}
+		p.Env[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return scanner.Err()
+}
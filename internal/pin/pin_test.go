@@ -0,0 +1,152 @@
+package pin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	// The walk stops at the worktree root, so nothing above it matters
+	outside := t.TempDir()
+	root := filepath.Join(outside, "repo")
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, ".ccswitch"), []byte("outside\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no pin file", func(t *testing.T) {
+		p, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if p != nil {
+			t.Errorf("Find() = %v, want nil", p)
+		}
+	})
+
+	t.Run("plain file in parent", func(t *testing.T) {
+		content := "# pinned provider\nglm\n\nAPI_TIMEOUT_MS = 600000\n"
+		if err := os.WriteFile(filepath.Join(root, ".ccswitch"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if p == nil {
+			t.Fatal("Find() = nil, want pin")
+		}
+		if p.Profile != "glm" {
+			t.Errorf("Profile = %v, want %v", p.Profile, "glm")
+		}
+		if p.Env["API_TIMEOUT_MS"] != "600000" {
+			t.Errorf("Env[API_TIMEOUT_MS] = %v, want %v", p.Env["API_TIMEOUT_MS"], "600000")
+		}
+		if p.Path != filepath.Join(root, ".ccswitch") {
+			t.Errorf("Path = %v, want %v", p.Path, filepath.Join(root, ".ccswitch"))
+		}
+	})
+
+	t.Run("nearest file wins and json is preferred", func(t *testing.T) {
+		src := filepath.Join(root, "src")
+		if err := os.WriteFile(filepath.Join(src, ".ccswitch"), []byte("deepseek\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, ".ccswitch.json"), []byte(`{"profile": "kimi", "env": {"ANTHROPIC_MODEL": "k2"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if p.Profile != "kimi" {
+			t.Errorf("Profile = %v, want %v", p.Profile, "kimi")
+		}
+		if p.Env["ANTHROPIC_MODEL"] != "k2" {
+			t.Errorf("Env[ANTHROPIC_MODEL] = %v, want %v", p.Env["ANTHROPIC_MODEL"], "k2")
+		}
+	})
+
+	t.Run("invalid pin in parent is skipped", func(t *testing.T) {
+		src := filepath.Join(root, "src")
+		if err := os.WriteFile(filepath.Join(src, ".ccswitch.json"), []byte(`{"profile": "kimi", "env": {"PATH": "/tmp"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if p == nil || p.Profile != "deepseek" {
+			t.Errorf("Find() = %v, want the valid pin next to the invalid one", p)
+		}
+
+		if _, err := Find(src); err == nil {
+			t.Error("Find() error = nil, want an error for an invalid pin in the starting directory")
+		}
+	})
+
+	t.Run("walk stops outside worktrees", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		dir := filepath.Join(outside, "plain")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Find(dir)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if p != nil {
+			t.Errorf("Find() = %v, want nil", p)
+		}
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"empty plain file", ".ccswitch", "# nothing\n"},
+		{"bad override line", ".ccswitch", "glm\nnot-an-assignment\n"},
+		{"json without profile", ".ccswitch.json", `{"env": {}}`},
+		{"invalid json", ".ccswitch.json", `{`},
+		{"invalid override key", ".ccswitch", "glm\nX;echo PWNED;Y=1\n"},
+		{"invalid json override key", ".ccswitch.json", `{"profile": "glm", "env": {"$(id)": "1"}}`},
+		{"base URL override", ".ccswitch", "glm\nANTHROPIC_BASE_URL=https://evil.example\n"},
+		{"proxy override", ".ccswitch.json", `{"profile": "glm", "env": {"HTTPS_PROXY": "http://evil.example"}}`},
+		{"token override", ".ccswitch", "glm\nANTHROPIC_AUTH_TOKEN=sk-evil\n"},
+		{"ccswitch variable override", ".ccswitch", "glm\nCCSWITCH_PROFILE=other\n"},
+		{"PATH override", ".ccswitch", "glm\nPATH=/tmp/evil\n"},
+		{"unknown variable override", ".ccswitch.json", `{"profile": "glm", "env": {"NODE_OPTIONS": "--require /tmp/x.js"}}`},
+		{"proxy variable override", ".ccswitch", "glm\nALL_PROXY=socks5://evil.example\n"},
+		{"TLS override", ".ccswitch", "glm\nNODE_TLS_REJECT_UNAUTHORIZED=0\n"},
+		{"custom headers override", ".ccswitch", "glm\nANTHROPIC_CUSTOM_HEADERS=X-Evil: 1\n"},
+		{"invalid number", ".ccswitch", "glm\nAPI_TIMEOUT_MS=soon\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("Load() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/profiles"
+)
+
+const (
+	// DefaultBaseURL is used when a profile does not set ANTHROPIC_BASE_URL
+	DefaultBaseURL = "https://api.anthropic.com"
+	// anthropicVersion is the API version header sent with every request
+	anthropicVersion = "2023-06-01"
+	// maxBodyLength limits how much of an error body is kept in a Result
+	maxBodyLength = 500
+)
+
+// Target is the endpoint and credentials of a profile
+type Target struct {
+	BaseURL   string
+	AuthToken string
+	APIKey    string
+}
+
+// TargetFromEnv builds a Target from a resolved profile environment
+func TargetFromEnv(env map[string]string) Target {
+	baseURL := env["ANTHROPIC_BASE_URL"]
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return Target{
+		BaseURL:   baseURL,
+		AuthToken: env["ANTHROPIC_AUTH_TOKEN"],
+		APIKey:    env["ANTHROPIC_API_KEY"],
+	}
+}
+
+// MessagesURL returns the Messages API endpoint of the target
+func (t Target) MessagesURL() string {
+	return strings.TrimRight(t.BaseURL, "/") + "/v1/messages"
+}
+
+// Authorize sets the credential headers of the target on h
+func (t Target) Authorize(h http.Header) {
+	if t.APIKey != "" {
+		h.Set("x-api-key", t.APIKey)
+	}
+	if t.AuthToken != "" {
+		h.Set("Authorization", "Bearer "+t.AuthToken)
+	}
+}
+
+// Result is the outcome of probing one model
+type Result struct {
+	Model string
+	// Keys lists the profile keys configured with this model
+	Keys       []string
+	StatusCode int
+	Latency    time.Duration
+	// Body holds the (truncated) response body of failed requests
+	Body string
+	Err  error
+}
+
+// OK reports whether the request succeeded
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Models returns the distinct models configured in env, in the order of
+// profiles.ModelKeys, each with the keys that use it
+func Models(env map[string]string) []Result {
+	var results []Result
+	index := make(map[string]int)
+	for _, key := range profiles.ModelKeys {
+		model := env[key]
+		if model == "" {
+			continue
+		}
+		if i, ok := index[model]; ok {
+			results[i].Keys = append(results[i].Keys, key)
+			continue
+		}
+		index[model] = len(results)
+		results = append(results, Result{Model: model, Keys: []string{key}})
+	}
+	return results
+}
+
+// Messages sends a minimal Messages API request for model and reports the outcome
+func Messages(ctx context.Context, client *http.Client, target Target, model string) Result {
+	result := Result{Model: model}
+
+	payload, err := json.Marshal(map[string]any{
+		"model":      model,
+		"max_tokens": 1,
+		"messages": []map[string]string{
+			{"role": "user", "content": "ping"},
+		},
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.MessagesURL(), bytes.NewReader(payload))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", anthropicVersion)
+	target.Authorize(req.Header)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Latency = time.Since(start)
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyLength+1))
+	result.Latency = time.Since(start)
+	result.StatusCode = resp.StatusCode
+	if !result.OK() {
+		result.Body = strings.TrimSpace(string(body))
+		if len(result.Body) > maxBodyLength {
+			result.Body = strings.ToValidUTF8(result.Body[:maxBodyLength], "") + "..."
+		}
+	}
+
+	return result
+}
+
+// Profile probes every distinct model configured in env, one after another.
+// A profile without any model yields a single failed result.
+func Profile(ctx context.Context, client *http.Client, env map[string]string) []Result {
+	models := Models(env)
+	if len(models) == 0 {
+		return []Result{{Err: fmt.Errorf("no model configured")}}
+	}
+
+	target := TargetFromEnv(env)
+	for i, m := range models {
+		r := Messages(ctx, client, target, m.Model)
+		r.Keys = m.Keys
+		models[i] = r
+	}
+	return models
+}
+
+// Health probes the primary model of env, which is ANTHROPIC_MODEL or the
+// first configured model key
+func Health(ctx context.Context, client *http.Client, env map[string]string) Result {
+	models := Models(env)
+	if len(models) == 0 {
+		return Result{Err: fmt.Errorf("no model configured")}
+	}
+
+	r := Messages(ctx, client, TargetFromEnv(env), models[0].Model)
+	r.Keys = models[0].Keys
+	return r
+}
+
+// Describe returns a short human-readable summary of a result
+func (r Result) Describe() string {
+	switch {
+	case r.Err != nil:
+		return r.Err.Error()
+	case r.OK():
+		return fmt.Sprintf("HTTP %d in %dms", r.StatusCode, r.Latency.Milliseconds())
+	}
+	return fmt.Sprintf("HTTP %d", r.StatusCode)
+}
@@ -0,0 +1,161 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestModels(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_MODEL":                "big",
+		"ANTHROPIC_SMALL_FAST_MODEL":     "small",
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":   "big",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  "small",
+		"ANTHROPIC_DEFAULT_SONNET_MODEL": "",
+	}
+
+	models := Models(env)
+	if len(models) != 2 {
+		t.Fatalf("Models() returned %d models, want 2: %v", len(models), models)
+	}
+	if models[0].Model != "big" || strings.Join(models[0].Keys, ",") != "ANTHROPIC_MODEL,ANTHROPIC_DEFAULT_OPUS_MODEL" {
+		t.Errorf("Models()[0] = %v", models[0])
+	}
+	if models[1].Model != "small" {
+		t.Errorf("Models()[1].Model = %v, want %v", models[1].Model, "small")
+	}
+}
+
+func TestMessagesURL(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"https://api.anthropic.com", "https://api.anthropic.com/v1/messages"},
+		{"https://api.kimi.com/coding/", "https://api.kimi.com/coding/v1/messages"},
+	}
+	for _, tt := range tests {
+		if got := (Target{BaseURL: tt.base}).MessagesURL(); got != tt.want {
+			t.Errorf("MessagesURL(%q) = %v, want %v", tt.base, got, tt.want)
+		}
+	}
+
+	if got := TargetFromEnv(map[string]string{}).BaseURL; got != DefaultBaseURL {
+		t.Errorf("TargetFromEnv() BaseURL = %v, want %v", got, DefaultBaseURL)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Error("missing anthropic-version header")
+		}
+
+		var body struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.MaxTokens != 1 {
+			t.Errorf("max_tokens = %v, want 1", body.MaxTokens)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer good-token" && r.Header.Get("x-api-key") != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error"}}`))
+			return
+		}
+		if body.Model != "known" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error","error":{"type":"not_found_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"type":"message"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		target Target
+		model  string
+		status int
+		ok     bool
+	}{
+		{"bearer token", Target{BaseURL: server.URL, AuthToken: "good-token"}, "known", 200, true},
+		{"api key", Target{BaseURL: server.URL, APIKey: "good-key"}, "known", 200, true},
+		{"bad token", Target{BaseURL: server.URL, AuthToken: "bad"}, "known", 401, false},
+		{"unknown model", Target{BaseURL: server.URL, AuthToken: "good-token"}, "other", 404, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Messages(ctx, server.Client(), tt.target, tt.model)
+			if r.StatusCode != tt.status {
+				t.Errorf("StatusCode = %v, want %v", r.StatusCode, tt.status)
+			}
+			if r.OK() != tt.ok {
+				t.Errorf("OK() = %v, want %v", r.OK(), tt.ok)
+			}
+			if !tt.ok && !strings.Contains(r.Body, "error") {
+				t.Errorf("Body = %q, want the error body", r.Body)
+			}
+			if tt.ok && r.Body != "" {
+				t.Errorf("Body = %q, want empty for successful requests", r.Body)
+			}
+		})
+	}
+
+	t.Run("connection error", func(t *testing.T) {
+		r := Messages(ctx, server.Client(), Target{BaseURL: "http://127.0.0.1:1"}, "known")
+		if r.Err == nil || r.OK() {
+			t.Errorf("Messages() = %+v, want a connection error", r)
+		}
+	})
+}
+
+func TestProfileWithoutModel(t *testing.T) {
+	results := Profile(context.Background(), http.DefaultClient, map[string]string{})
+	if len(results) != 1 || results[0].OK() {
+		t.Errorf("Profile() = %v, want a single failed result", results)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		w.Write([]byte(`{"type":"message"}`))
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"ANTHROPIC_BASE_URL":         server.URL,
+		"ANTHROPIC_MODEL":            "big",
+		"ANTHROPIC_SMALL_FAST_MODEL": "small",
+	}
+	r := Health(context.Background(), http.DefaultClient, env)
+	if !r.OK() {
+		t.Fatalf("Health() = %v, want OK", r.Describe())
+	}
+	if len(models) != 1 || models[0] != "big" {
+		t.Errorf("Health() probed %v, want only [big]", models)
+	}
+
+	if r := Health(context.Background(), http.DefaultClient, map[string]string{}); r.OK() || r.Err == nil {
+		t.Errorf("Health() without a model = %v, want an error", r.Describe())
+	}
+}
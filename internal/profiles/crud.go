@@ -0,0 +1,309 @@
+package profiles
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/secrets"
+	"github.com/huangdijia/ccswitch/internal/settings"
+)
+
+// Definition is a profile's own environment together with its metadata, as
+// stored across the maps of Config
+type Definition struct {
+	Description  string            `json:"description,omitempty"`
+	Extends      StringList        `json:"extends,omitempty"`
+	Fallbacks    StringList        `json:"fallbacks,omitempty"`
+	ModelMap     ModelMap          `json:"modelMap,omitempty"`
+	Settings     map[string]any    `json:"settings,omitempty"`
+	TokenCommand *TokenCommand     `json:"tokenCommand,omitempty"`
+	Env          map[string]string `json:"env"`
+}
+
+// Definition returns a copy of the profile's own values and metadata
+func (p *Profiles) Definition(name string) (*Definition, error) {
+	if !p.Has(name) {
+		return nil, fmt.Errorf("profile '%s' not found", name)
+	}
+
+	env := maps.Clone(p.Data.Profiles[name])
+	if env == nil {
+		env = make(map[string]string)
+	}
+	def := &Definition{
+		Description: p.Data.Descriptions[name],
+		Extends:     slices.Clone(p.Data.Extends[name]),
+		Fallbacks:   slices.Clone(p.Data.Fallbacks[name]),
+		ModelMap:    slices.Clone(p.Data.ModelMaps[name]),
+		Settings:    cloneSettings(p.Data.Settings[name]),
+		Env:         env,
+	}
+	if command, ok := p.Data.TokenCommands[name]; ok {
+		def.TokenCommand = &command
+	}
+	return def, nil
+}
+
+// Put stores def under name, replacing the profile and all of its metadata
+func (p *Profiles) Put(name string, def *Definition) {
+	p.Delete(name)
+
+	env := maps.Clone(def.Env)
+	if env == nil {
+		env = make(map[string]string)
+	}
+	if p.Data.Profiles == nil {
+		p.Data.Profiles = make(map[string]map[string]string)
+	}
+	p.Data.Profiles[name] = env
+
+	if def.Description != "" {
+		if p.Data.Descriptions == nil {
+			p.Data.Descriptions = make(map[string]string)
+		}
+		p.Data.Descriptions[name] = def.Description
+	}
+	if len(def.Extends) > 0 {
+		if p.Data.Extends == nil {
+			p.Data.Extends = make(map[string]StringList)
+		}
+		p.Data.Extends[name] = slices.Clone(def.Extends)
+	}
+	if len(def.Fallbacks) > 0 {
+		if p.Data.Fallbacks == nil {
+			p.Data.Fallbacks = make(map[string]StringList)
+		}
+		p.Data.Fallbacks[name] = slices.Clone(def.Fallbacks)
+	}
+	if len(def.ModelMap) > 0 {
+		if p.Data.ModelMaps == nil {
+			p.Data.ModelMaps = make(map[string]ModelMap)
+		}
+		rules := slices.Clone(def.ModelMap)
+		for i := range rules {
+			rules[i].Source = ""
+		}
+		p.Data.ModelMaps[name] = rules
+	}
+	if len(def.Settings) > 0 {
+		if p.Data.Settings == nil {
+			p.Data.Settings = make(map[string]map[string]any)
+		}
+		p.Data.Settings[name] = cloneSettings(def.Settings)
+	}
+	if def.TokenCommand != nil {
+		if p.Data.TokenCommands == nil {
+			p.Data.TokenCommands = make(map[string]TokenCommand)
+		}
+		p.Data.TokenCommands[name] = *def.TokenCommand
+	}
+}
+
+// cloneSettings deep-copies a settings object, returning nil for an empty one
+func cloneSettings(values map[string]any) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	return settings.DeepMerge(nil, values)
+}
+
+// Delete removes a profile and all of its metadata without checking whether
+// other profiles refer to it. Use Remove for a checked removal
+func (p *Profiles) Delete(name string) {
+	delete(p.Data.Profiles, name)
+	delete(p.Data.Descriptions, name)
+	delete(p.Data.Extends, name)
+	delete(p.Data.Fallbacks, name)
+	delete(p.Data.ModelMaps, name)
+	delete(p.Data.Settings, name)
+	delete(p.Data.TokenCommands, name)
+}
+
+// Remove deletes a profile. The default profile and profiles that others
+// extend cannot be removed; the profile is dropped from other profiles'
+// fallbacks
+func (p *Profiles) Remove(name string) error {
+	if !p.Has(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	if name == p.Data.Default {
+		return fmt.Errorf("profile '%s' is the default profile; choose another default first", name)
+	}
+	if children := p.extendedBy(name); len(children) > 0 {
+		return fmt.Errorf("profile '%s' is extended by %s", name, strings.Join(children, ", "))
+	}
+
+	p.Delete(name)
+	for owner, fallbacks := range p.Data.Fallbacks {
+		fallbacks = slices.DeleteFunc(fallbacks, func(f string) bool { return f == name })
+		if len(fallbacks) == 0 {
+			delete(p.Data.Fallbacks, owner)
+		} else {
+			p.Data.Fallbacks[owner] = fallbacks
+		}
+	}
+	return nil
+}
+
+// Rename renames a profile, updating the default and every profile that
+// extends it or falls back to it
+func (p *Profiles) Rename(oldName, newName string) error {
+	def, err := p.Definition(oldName)
+	if err != nil {
+		return err
+	}
+	if newName == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if p.Has(newName) {
+		return fmt.Errorf("profile '%s' already exists", newName)
+	}
+
+	p.Delete(oldName)
+	p.Put(newName, def)
+	p.rekeySecretRefs(newName, oldName)
+
+	if p.Data.Default == oldName {
+		p.Data.Default = newName
+	}
+	for _, lists := range []map[string]StringList{p.Data.Extends, p.Data.Fallbacks} {
+		for _, list := range lists {
+			for i, ref := range list {
+				if ref == oldName {
+					list[i] = newName
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Copy duplicates a profile and its metadata under a new name
+func (p *Profiles) Copy(src, dst string) error {
+	def, err := p.Definition(src)
+	if err != nil {
+		return err
+	}
+	if dst == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if p.Has(dst) {
+		return fmt.Errorf("profile '%s' already exists", dst)
+	}
+
+	p.Put(dst, def)
+	p.rekeySecretRefs(dst, src)
+	return nil
+}
+
+// OwnSecrets returns the keys of the profile's own environment that refer to
+// secrets stored under the profile's name, sorted. Those secrets belong to the
+// profile: they move with renames, are duplicated by copies and go away when
+// the profile is removed.
+func (p *Profiles) OwnSecrets(name string) []string {
+	var keys []string
+	for key, value := range p.Data.Profiles[name] {
+		if value == secrets.Ref(secrets.Name(name, key)) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// rekeySecretRefs points the references name inherited from the secrets owned
+// by profile from at the same keys under name
+func (p *Profiles) rekeySecretRefs(name, from string) {
+	env := p.Data.Profiles[name]
+	for key, value := range env {
+		if value == secrets.Ref(secrets.Name(from, key)) {
+			env[key] = secrets.Ref(secrets.Name(name, key))
+		}
+	}
+}
+
+// SetEnv sets variables in the profile's own environment after checking
+// them against the schema. Nothing is changed if any value is invalid.
+func (p *Profiles) SetEnv(name string, env map[string]string) error {
+	if !p.Has(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		if err := ValidateValue(key, env[key]); err != nil {
+			return err
+		}
+	}
+
+	if p.Data.Profiles[name] == nil {
+		p.Data.Profiles[name] = make(map[string]string)
+	}
+	maps.Copy(p.Data.Profiles[name], env)
+	return nil
+}
+
+// UnsetEnv removes variables from the profile's own environment. Keys the
+// profile does not set itself are reported as an error and nothing is removed.
+func (p *Profiles) UnsetEnv(name string, keys []string) error {
+	if !p.Has(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	own := p.Data.Profiles[name]
+	for _, key := range keys {
+		if _, ok := own[key]; ok {
+			continue
+		}
+		if resolved, err := p.Resolve(name); err == nil {
+			if source, ok := resolved.Sources[key]; ok && source != DefaultedSource {
+				return fmt.Errorf("%s is inherited from profile '%s'; unset it there", key, source)
+			}
+		}
+		return fmt.Errorf("%s is not set in profile '%s'", key, name)
+	}
+
+	for _, key := range keys {
+		delete(own, key)
+	}
+	return nil
+}
+
+// SetDefault makes name the default profile
+func (p *Profiles) SetDefault(name string) error {
+	if !p.Has(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	p.Data.Default = name
+	return nil
+}
+
+// ValidateProfile checks that a profile and the profiles extending it
+// resolve, and that its fallbacks refer to existing profiles
+func (p *Profiles) ValidateProfile(name string) error {
+	if _, err := p.Resolve(name); err != nil {
+		return err
+	}
+	for _, fallback := range p.Data.Fallbacks[name] {
+		if !p.Has(fallback) {
+			return fmt.Errorf("profile '%s' falls back to unknown profile '%s'", name, fallback)
+		}
+	}
+	for _, child := range p.extendedBy(name) {
+		if _, err := p.Resolve(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extendedBy returns the profiles that list name in their extends, sorted
+func (p *Profiles) extendedBy(name string) []string {
+	var children []string
+	for child, parents := range p.Data.Extends {
+		if slices.Contains(parents, name) {
+			children = append(children, child)
+		}
+	}
+	slices.Sort(children)
+	return children
+}
@@ -0,0 +1,168 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestProfiles(t *testing.T) *Profiles {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ccs.json")
+	data := `{
+		"default": "base",
+		"profiles": {
+			"base": {"ANTHROPIC_MODEL": "base-model"},
+			"glm": {"ANTHROPIC_MODEL": "GLM-4.6"},
+			"kimi": {"ANTHROPIC_MODEL": "kimi-k2"}
+		},
+		"descriptions": {"glm": "Zhipu GLM"},
+		"extends": {"glm": "base"},
+		"fallbacks": {"kimi": ["glm", "base"]},
+		"modelMap": {"glm": {"*opus*": "GLM-4.6"}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profs, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profs
+}
+
+func TestRemove(t *testing.T) {
+	profs := newTestProfiles(t)
+
+	if err := profs.Remove("base"); err == nil {
+		t.Error("Remove() of the default profile expected error")
+	}
+	if err := profs.SetDefault("kimi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := profs.Remove("base"); err == nil {
+		t.Error("Remove() of an extended profile expected error")
+	}
+
+	if err := profs.Remove("glm"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if profs.Has("glm") || profs.Data.Descriptions["glm"] != "" || profs.Data.Extends["glm"] != nil || profs.Data.ModelMaps["glm"] != nil {
+		t.Error("Remove() left metadata behind")
+	}
+	if got := profs.Data.Fallbacks["kimi"]; !slices.Equal(got, StringList{"base"}) {
+		t.Errorf("Fallbacks[kimi] = %v, want [base]", got)
+	}
+	if err := profs.Remove("missing"); err == nil {
+		t.Error("Remove() of an unknown profile expected error")
+	}
+}
+
+func TestRename(t *testing.T) {
+	profs := newTestProfiles(t)
+
+	if err := profs.Rename("base", "common"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if profs.Has("base") || !profs.Has("common") {
+		t.Error("Rename() did not move the profile")
+	}
+	if profs.Data.Default != "common" {
+		t.Errorf("Default = %v, want common", profs.Data.Default)
+	}
+	if got := profs.Data.Extends["glm"]; !slices.Equal(got, StringList{"common"}) {
+		t.Errorf("Extends[glm] = %v, want [common]", got)
+	}
+	if got := profs.Data.Fallbacks["kimi"]; !slices.Equal(got, StringList{"glm", "common"}) {
+		t.Errorf("Fallbacks[kimi] = %v, want [glm common]", got)
+	}
+
+	if err := profs.Rename("glm", "zhipu"); err != nil {
+		t.Fatal(err)
+	}
+	if profs.Data.Descriptions["zhipu"] != "Zhipu GLM" || len(profs.Data.ModelMaps["zhipu"]) != 1 {
+		t.Error("Rename() did not move metadata")
+	}
+
+	if err := profs.Rename("kimi", "zhipu"); err == nil {
+		t.Error("Rename() onto an existing profile expected error")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	profs := newTestProfiles(t)
+
+	if err := profs.Copy("glm", "glm-2"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if profs.Data.Descriptions["glm-2"] != "Zhipu GLM" || !slices.Equal(profs.Data.Extends["glm-2"], StringList{"base"}) {
+		t.Error("Copy() did not copy metadata")
+	}
+
+	profs.Data.Settings = map[string]map[string]any{"glm": {"statusLine": map[string]any{"type": "command"}}}
+	if err := profs.Copy("glm", "glm-3"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	profs.Data.Settings["glm-3"]["statusLine"].(map[string]any)["type"] = "changed"
+	if profs.Data.Settings["glm"]["statusLine"].(map[string]any)["type"] != "command" {
+		t.Error("Copy() shares settings with the source")
+	}
+
+	profs.Data.Profiles["glm-2"]["ANTHROPIC_MODEL"] = "changed"
+	if profs.Data.Profiles["glm"]["ANTHROPIC_MODEL"] != "GLM-4.6" {
+		t.Error("Copy() shares the environment with the source")
+	}
+
+	if err := profs.Copy("glm", "kimi"); err == nil {
+		t.Error("Copy() onto an existing profile expected error")
+	}
+}
+
+func TestSecretRefsFollowProfile(t *testing.T) {
+	profs := newTestProfiles(t)
+	profs.Data.Profiles["glm"]["ANTHROPIC_AUTH_TOKEN"] = "secret://glm/ANTHROPIC_AUTH_TOKEN"
+	profs.Data.Profiles["glm"]["ANTHROPIC_API_KEY"] = "secret://kimi/ANTHROPIC_API_KEY"
+
+	if got := profs.OwnSecrets("glm"); !slices.Equal(got, []string{"ANTHROPIC_AUTH_TOKEN"}) {
+		t.Errorf("OwnSecrets() = %v, want [ANTHROPIC_AUTH_TOKEN]", got)
+	}
+
+	if err := profs.Copy("glm", "glm-2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := profs.Data.Profiles["glm-2"]["ANTHROPIC_AUTH_TOKEN"]; got != "secret://glm-2/ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("copied ref = %v, want %v", got, "secret://glm-2/ANTHROPIC_AUTH_TOKEN")
+	}
+	if got := profs.Data.Profiles["glm"]["ANTHROPIC_AUTH_TOKEN"]; got != "secret://glm/ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("source ref = %v, want %v", got, "secret://glm/ANTHROPIC_AUTH_TOKEN")
+	}
+
+	if err := profs.Rename("glm", "zhipu"); err != nil {
+		t.Fatal(err)
+	}
+	env := profs.Data.Profiles["zhipu"]
+	if got := env["ANTHROPIC_AUTH_TOKEN"]; got != "secret://zhipu/ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("renamed ref = %v, want %v", got, "secret://zhipu/ANTHROPIC_AUTH_TOKEN")
+	}
+	if got := env["ANTHROPIC_API_KEY"]; got != "secret://kimi/ANTHROPIC_API_KEY" {
+		t.Errorf("ref to another profile's secret = %v, want it unchanged", got)
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	profs := newTestProfiles(t)
+	if err := profs.ValidateProfile("glm"); err != nil {
+		t.Errorf("ValidateProfile() error = %v", err)
+	}
+
+	profs.Put("base", &Definition{Env: map[string]string{}, Extends: StringList{"glm"}})
+	if err := profs.ValidateProfile("base"); err == nil {
+		t.Error("ValidateProfile() expected a cycle error")
+	}
+
+	profs.Put("base", &Definition{Env: map[string]string{}, Fallbacks: StringList{"missing"}})
+	if err := profs.ValidateProfile("base"); err == nil {
+		t.Error("ValidateProfile() expected an unknown fallback error")
+	}
+}
@@ -0,0 +1,118 @@
+package profiles
+
+import (
+	"sort"
+
+	"github.com/huangdijia/ccswitch/internal/secrets"
+)
+
+// Diff is a key whose value in the settings differs from the profile
+type Diff struct {
+	Key string
+	// Want is the profile's value, empty when the profile does not set the key
+	Want string
+	// Got is the settings value, empty when the settings do not set the key
+	Got string
+}
+
+// Match is the profile that best matches a settings environment
+type Match struct {
+	Profile string
+	// Diffs lists the differences between the profile and the settings;
+	// it is empty for an exact match
+	Diffs []Diff
+	// Unverified lists the keys that only match because the profile holds a
+	// secret reference there, whose stored value is not compared
+	Unverified []string
+	// Ambiguous lists the other candidates that match as well as Profile
+	Ambiguous []string
+}
+
+// Exact reports whether the settings match the profile exactly
+func (m *Match) Exact() bool {
+	return len(m.Diffs) == 0
+}
+
+// MatchEnv finds the candidate whose environment matches env. An exact match
+// wins; otherwise the candidate with the fewest differences that shares the
+// base URL is returned as a drifted match. It returns nil when env is empty
+// or no candidate is close, meaning the settings are custom.
+//
+// Candidate values that are secret references match any non-empty value,
+// since the settings hold the resolved secret. Such matches are weaker than
+// literal ones: between candidates with as many differences, the one with
+// fewer unverified keys wins, and candidates that still tie are reported in
+// Ambiguous.
+func MatchEnv(env map[string]string, candidates map[string]map[string]string) *Match {
+	if len(env) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var best *Match
+	for _, name := range names {
+		want := candidates[name]
+		if want["ANTHROPIC_BASE_URL"] != env["ANTHROPIC_BASE_URL"] {
+			continue
+		}
+
+		diffs, unverified := diffEnv(want, env)
+		if len(diffs) == len(unionKeys(want, env)) {
+			continue
+		}
+		switch {
+		case best == nil || len(diffs) < len(best.Diffs) ||
+			len(diffs) == len(best.Diffs) && len(unverified) < len(best.Unverified):
+			best = &Match{Profile: name, Diffs: diffs, Unverified: unverified}
+		case len(diffs) == len(best.Diffs) && len(unverified) == len(best.Unverified):
+			best.Ambiguous = append(best.Ambiguous, name)
+		}
+	}
+	return best
+}
+
+// CompareEnv returns the keys whose values differ between want and got,
+// sorted by key
+func CompareEnv(want, got map[string]string) []Diff {
+	var diffs []Diff
+	for _, key := range unionKeys(want, got) {
+		if w, g := want[key], got[key]; w != g {
+			diffs = append(diffs, Diff{Key: key, Want: w, Got: g})
+		}
+	}
+	return diffs
+}
+
+// diffEnv is CompareEnv with secret references in want matching any
+// non-empty value in got. The keys matched that way are returned as
+// unverified
+func diffEnv(want, got map[string]string) (diffs []Diff, unverified []string) {
+	for _, d := range CompareEnv(want, got) {
+		if secrets.IsRef(d.Want) && d.Got != "" {
+			unverified = append(unverified, d.Key)
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, unverified
+}
+
+func unionKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
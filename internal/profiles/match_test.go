@@ -0,0 +1,86 @@
+package profiles
+
+import "testing"
+
+func TestMatchEnv(t *testing.T) {
+	candidates := map[string]map[string]string{
+		"glm": {
+			"ANTHROPIC_BASE_URL":   "https://glm",
+			"ANTHROPIC_AUTH_TOKEN": "secret://glm/ANTHROPIC_AUTH_TOKEN",
+			"ANTHROPIC_MODEL":      "GLM-4.6",
+		},
+		"glm-air": {
+			"ANTHROPIC_BASE_URL":   "https://glm",
+			"ANTHROPIC_AUTH_TOKEN": "token",
+			"ANTHROPIC_MODEL":      "GLM-4.5-Air",
+		},
+		"kimi": {
+			"ANTHROPIC_BASE_URL": "https://kimi",
+			"ANTHROPIC_MODEL":    "kimi-k2",
+		},
+	}
+
+	t.Run("exact match resolves secret references", func(t *testing.T) {
+		m := MatchEnv(map[string]string{
+			"ANTHROPIC_BASE_URL":   "https://glm",
+			"ANTHROPIC_AUTH_TOKEN": "resolved-token",
+			"ANTHROPIC_MODEL":      "GLM-4.6",
+		}, candidates)
+		if m == nil || m.Profile != "glm" || !m.Exact() {
+			t.Errorf("MatchEnv() = %+v, want exact glm", m)
+		}
+	})
+
+	t.Run("closest match with diff", func(t *testing.T) {
+		m := MatchEnv(map[string]string{
+			"ANTHROPIC_BASE_URL":   "https://glm",
+			"ANTHROPIC_AUTH_TOKEN": "token",
+			"ANTHROPIC_MODEL":      "GLM-4.5-Air",
+			"API_TIMEOUT_MS":       "1000",
+		}, candidates)
+		if m == nil || m.Profile != "glm-air" || len(m.Diffs) != 1 {
+			t.Fatalf("MatchEnv() = %+v, want glm-air with one diff", m)
+		}
+		if d := m.Diffs[0]; d.Key != "API_TIMEOUT_MS" || d.Want != "" || d.Got != "1000" {
+			t.Errorf("Diffs[0] = %+v", d)
+		}
+	})
+
+	t.Run("custom settings", func(t *testing.T) {
+		m := MatchEnv(map[string]string{"ANTHROPIC_BASE_URL": "https://elsewhere", "ANTHROPIC_MODEL": "x"}, candidates)
+		if m != nil {
+			t.Errorf("MatchEnv() = %+v, want nil", m)
+		}
+	})
+
+	t.Run("empty settings", func(t *testing.T) {
+		if m := MatchEnv(map[string]string{}, candidates); m != nil {
+			t.Errorf("MatchEnv() = %+v, want nil", m)
+		}
+	})
+
+	t.Run("literal token beats a secret reference", func(t *testing.T) {
+		candidates := map[string]map[string]string{
+			"a": {"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "secret://a/ANTHROPIC_AUTH_TOKEN"},
+			"b": {"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "token-b"},
+		}
+		m := MatchEnv(map[string]string{"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "token-b"}, candidates)
+		if m == nil || m.Profile != "b" || len(m.Unverified) != 0 || len(m.Ambiguous) != 0 {
+			t.Errorf("MatchEnv() = %+v, want b", m)
+		}
+	})
+
+	t.Run("secret references alone are ambiguous", func(t *testing.T) {
+		candidates := map[string]map[string]string{
+			"a": {"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "secret://a/ANTHROPIC_AUTH_TOKEN"},
+			"b": {"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "secret://b/ANTHROPIC_AUTH_TOKEN"},
+		}
+		m := MatchEnv(map[string]string{"ANTHROPIC_BASE_URL": "https://glm", "ANTHROPIC_AUTH_TOKEN": "token"}, candidates)
+		if m == nil || m.Profile != "a" || !m.Exact() {
+			t.Fatalf("MatchEnv() = %+v, want exact a", m)
+		}
+		if len(m.Unverified) != 1 || len(m.Ambiguous) != 1 || m.Ambiguous[0] != "b" {
+			t.Errorf("MatchEnv() = %+v, want b reported as ambiguous", m)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package profiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MappedSource is the source recorded for model keys filled from a modelMap
+const MappedSource = "(mapped by modelMap)"
+
+// familyAliases are the names looked up in a modelMap to fill the model keys
+// Claude Code uses for each model family
+var familyAliases = []struct {
+	Key   string
+	Alias string
+}{
+	{"ANTHROPIC_DEFAULT_OPUS_MODEL", "opus"},
+	{"ANTHROPIC_DEFAULT_SONNET_MODEL", "sonnet"},
+	{"ANTHROPIC_DEFAULT_HAIKU_MODEL", "haiku"},
+	{"ANTHROPIC_SMALL_FAST_MODEL", "haiku"},
+}
+
+// ModelRule maps model names matching a glob pattern to an upstream model
+type ModelRule struct {
+	Pattern string
+	Model   string
+	// Source is the profile that defined the rule; it is not serialized
+	Source string
+}
+
+// ModelMap is an ordered list of rules, written in JSON as an object whose
+// keys are glob patterns. The first matching rule wins
+type ModelMap []ModelRule
+
+// UnmarshalJSON reads a JSON object, keeping the order of its keys
+func (m *ModelMap) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("modelMap must be an object of pattern to model")
+	}
+
+	var rules ModelMap
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		pattern := tok.(string)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid modelMap pattern '%s': %w", pattern, err)
+		}
+
+		var model string
+		if err := dec.Decode(&model); err != nil {
+			return fmt.Errorf("modelMap pattern '%s' must map to a string: %w", pattern, err)
+		}
+		rules = append(rules, ModelRule{Pattern: pattern, Model: model})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	*m = rules
+	return nil
+}
+
+// MarshalJSON writes the rules as a JSON object in order
+func (m ModelMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, rule := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		pattern, err := json.Marshal(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		model, err := json.Marshal(rule.Model)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(pattern)
+		buf.WriteByte(':')
+		buf.Write(model)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Lookup returns the model of the first rule whose pattern matches model,
+// ignoring case
+func (m ModelMap) Lookup(model string) (string, bool) {
+	model = strings.ToLower(model)
+	for _, rule := range m {
+		if ok, _ := path.Match(strings.ToLower(rule.Pattern), model); ok {
+			return rule.Model, true
+		}
+	}
+	return "", false
+}
+
+// applyModelMap fills the family model keys that are not set explicitly from
+// the resolved modelMap
+func applyModelMap(resolved *Resolved) {
+	for _, family := range familyAliases {
+		if _, exists := resolved.Env[family.Key]; exists {
+			continue
+		}
+		if model, ok := resolved.ModelMap.Lookup(family.Alias); ok {
+			resolved.Env[family.Key] = model
+			resolved.Sources[family.Key] = MappedSource
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package profiles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModelMapJSON(t *testing.T) {
+	var m ModelMap
+	data := `{"*opus*": "GLM-4.6", "claude-*": "GLM-4.5", "*": "fallback"}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(m) != 3 || m[0].Pattern != "*opus*" || m[2].Pattern != "*" {
+		t.Fatalf("Unmarshal() = %v, want rules in file order", m)
+	}
+
+	out, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"*opus*":"GLM-4.6","claude-*":"GLM-4.5","*":"fallback"}` {
+		t.Errorf("Marshal() = %s", out)
+	}
+
+	for _, invalid := range []string{`["a"]`, `{"[": "x"}`, `{"a": 1}`} {
+		if err := json.Unmarshal([]byte(invalid), &m); err == nil {
+			t.Errorf("Unmarshal(%s) expected error", invalid)
+		}
+	}
+}
+
+func TestModelMapLookup(t *testing.T) {
+	m := ModelMap{
+		{Pattern: "*opus*", Model: "GLM-4.6"},
+		{Pattern: "claude-*", Model: "GLM-4.5"},
+	}
+
+	tests := []struct {
+		model string
+		want  string
+		ok    bool
+	}{
+		{"claude-opus-4-1-20250805", "GLM-4.6", true},
+		{"Claude-Sonnet-4-5", "GLM-4.5", true},
+		{"opus", "GLM-4.6", true},
+		{"gpt-5", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := m.Lookup(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolveModelMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ccs.json")
+	data := `{
+		"profiles": {
+			"base": {"ANTHROPIC_MODEL": "base-model"},
+			"glm": {"ANTHROPIC_MODEL": "GLM-4.6", "ANTHROPIC_DEFAULT_SONNET_MODEL": "explicit"}
+		},
+		"extends": {"glm": "base"},
+		"modelMap": {
+			"base": {"*haiku*": "base-haiku", "*opus*": "base-opus"},
+			"glm": {"*opus*": "GLM-4.6-opus", "*sonnet*": "ignored"}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profs, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := profs.Resolve("glm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":   "GLM-4.6-opus",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  "base-haiku",
+		"ANTHROPIC_SMALL_FAST_MODEL":     "base-haiku",
+		"ANTHROPIC_DEFAULT_SONNET_MODEL": "explicit",
+	}
+	for key, value := range want {
+		if resolved.Env[key] != value {
+			t.Errorf("Env[%s] = %v, want %v", key, resolved.Env[key], value)
+		}
+	}
+	if resolved.Sources["ANTHROPIC_DEFAULT_OPUS_MODEL"] != MappedSource {
+		t.Errorf("Sources[opus] = %v, want %v", resolved.Sources["ANTHROPIC_DEFAULT_OPUS_MODEL"], MappedSource)
+	}
+
+	if len(resolved.ModelMap) != 4 || resolved.ModelMap[0].Source != "glm" || resolved.ModelMap[2].Source != "base" {
+		t.Errorf("ModelMap = %v, want own rules before inherited ones", resolved.ModelMap)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
+	"github.com/huangdijia/ccswitch/internal/settings"
 )
 
 // defaultModelKeys are the environment variables that should default to ANTHROPIC_MODEL if not set
@@ -16,12 +19,74 @@ var defaultModelKeys = []string{
 	"ANTHROPIC_SMALL_FAST_MODEL",
 }
 
+// ModelKeys are all environment variables that name a model, primary model first
+var ModelKeys = append([]string{"ANTHROPIC_MODEL"}, defaultModelKeys...)
+
 // Config represents the profiles configuration
 type Config struct {
 	SettingsPath string                       `json:"settingsPath"`
 	Default      string                       `json:"default"`
 	Profiles     map[string]map[string]string `json:"profiles"`
 	Descriptions map[string]string            `json:"descriptions,omitempty"`
+	Extends      map[string]StringList        `json:"extends,omitempty"`
+	Fallbacks    map[string]StringList        `json:"fallbacks,omitempty"`
+	ModelMaps    map[string]ModelMap          `json:"modelMap,omitempty"`
+	// Settings holds other settings.json keys, such as permissions or hooks,
+	// merged into the settings file when a profile is used
+	Settings map[string]map[string]any `json:"settings,omitempty"`
+	// TokenCommands hold commands printing short-lived tokens, used through
+	// Claude Code's apiKeyHelper
+	TokenCommands map[string]TokenCommand `json:"tokenCommand,omitempty"`
+	// SecretsBackend names the store holding tokens referenced as secret://name
+	SecretsBackend string `json:"secretsBackend,omitempty"`
+}
+
+// StringList is a list of strings that can be written in JSON either as a
+// single string or as an array of strings
+type StringList []string
+
+// UnmarshalJSON accepts both "name" and ["a", "b"]
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*l = nil
+		} else {
+			*l = StringList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or an array of strings: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// MarshalJSON writes a single-element list as a plain string
+func (l StringList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
+}
+
+// DefaultedSource is the source recorded for model keys filled from ANTHROPIC_MODEL
+const DefaultedSource = "(defaulted from ANTHROPIC_MODEL)"
+
+// Resolved is a profile's environment after inheritance has been applied
+type Resolved struct {
+	Env map[string]string
+	// Sources maps each key in Env to the profile that supplied its value
+	Sources map[string]string
+	// ModelMap holds the profile's model rules followed by inherited ones
+	ModelMap ModelMap
+	// Settings holds the merged settings.json keys of the profile and its parents
+	Settings map[string]any
+	// TokenCommand is the profile's own or nearest inherited token command
+	TokenCommand *TokenCommand
 }
 
 // Profiles manages profile configurations
@@ -85,29 +150,100 @@ func (p *Profiles) Default() string {
 	return "default"
 }
 
-// Get returns the profile configuration with missing fields filled
+// Get returns the profile configuration with inherited and missing fields filled.
+// If the inheritance chain cannot be resolved, only the profile's own values are used.
 func (p *Profiles) Get(name string) map[string]string {
-	env, exists := p.Data.Profiles[name]
-	if !exists {
-		return make(map[string]string)
+	resolved, err := p.Resolve(name)
+	if err != nil {
+		if _, exists := p.Data.Profiles[name]; !exists {
+			return make(map[string]string)
+		}
+		resolved = &Resolved{Env: make(map[string]string), Sources: make(map[string]string)}
+		for k, v := range p.Data.Profiles[name] {
+			resolved.Env[k] = v
+		}
+		fillModelDefaults(resolved)
+	}
+	return resolved.Env
+}
+
+// Resolve returns the profile's environment with its extends chain applied.
+// Parents are applied in order, so later parents override earlier ones and the
+// profile itself overrides all of them.
+func (p *Profiles) Resolve(name string) (*Resolved, error) {
+	if !p.Has(name) {
+		return nil, fmt.Errorf("profile '%s' not found", name)
+	}
+
+	resolved := &Resolved{
+		Env:     make(map[string]string),
+		Sources: make(map[string]string),
+	}
+	if err := p.resolveInto(name, resolved, nil); err != nil {
+		return nil, err
 	}
 
-	// Make a copy to avoid modifying the original
-	result := make(map[string]string)
-	for k, v := range env {
-		result[k] = v
+	applyModelMap(resolved)
+	fillModelDefaults(resolved)
+
+	return resolved, nil
+}
+
+// resolveInto merges the profile and its ancestors into resolved, using chain
+// to detect cycles
+func (p *Profiles) resolveInto(name string, resolved *Resolved, chain []string) error {
+	for _, seen := range chain {
+		if seen == name {
+			return fmt.Errorf("profile inheritance cycle: %s", strings.Join(append(chain, name), " -> "))
+		}
 	}
+	chain = append(chain, name)
 
-	// Fill in missing model fields with ANTHROPIC_MODEL if present
-	if model, ok := result["ANTHROPIC_MODEL"]; ok {
-		for _, key := range defaultModelKeys {
-			if _, exists := result[key]; !exists {
-				result[key] = model
-			}
+	for _, parent := range p.Data.Extends[name] {
+		if !p.Has(parent) {
+			return fmt.Errorf("profile '%s' extends unknown profile '%s'", name, parent)
+		}
+		if err := p.resolveInto(parent, resolved, chain); err != nil {
+			return err
 		}
 	}
 
-	return result
+	for k, v := range p.Data.Profiles[name] {
+		resolved.Env[k] = v
+		resolved.Sources[k] = name
+	}
+
+	// The profile's own rules take precedence over those of its parents
+	var rules ModelMap
+	for _, rule := range p.Data.ModelMaps[name] {
+		rule.Source = name
+		rules = append(rules, rule)
+	}
+	resolved.ModelMap = append(rules, resolved.ModelMap...)
+
+	if own := p.Data.Settings[name]; len(own) > 0 {
+		resolved.Settings = settings.DeepMerge(resolved.Settings, own)
+	}
+
+	if command, ok := p.Data.TokenCommands[name]; ok {
+		resolved.TokenCommand = &command
+	}
+
+	return nil
+}
+
+// fillModelDefaults fills in missing model fields with ANTHROPIC_MODEL if present
+func fillModelDefaults(resolved *Resolved) {
+	model, ok := resolved.Env["ANTHROPIC_MODEL"]
+	if !ok {
+		return
+	}
+	for _, key := range defaultModelKeys {
+		if _, exists := resolved.Env[key]; !exists {
+			resolved.Env[key] = model
+			resolved.Sources[key] = DefaultedSource
+		}
+	}
 }
 
 // GetAll returns all profile names
@@ -135,12 +271,22 @@ func (p *Profiles) Add(name string, env map[string]string, description string) e
 
 // Save writes the profiles configuration to file
 func (p *Profiles) Save() error {
+	return p.save(atomicfile.WriteFile)
+}
+
+// SaveNoBackup is Save without a backup of the replaced file, for changes
+// that remove secrets the backup would otherwise keep
+func (p *Profiles) SaveNoBackup() error {
+	return p.save(atomicfile.WriteFileNoBackup)
+}
+
+func (p *Profiles) save(write func(path string, data []byte, perm os.FileMode) error) error {
 	data, err := json.MarshalIndent(p.Data, "", "    ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal profiles: %w", err)
 	}
 
-	if err := os.WriteFile(p.Path, data, 0644); err != nil {
+	if err := write(p.Path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write profiles file: %w", err)
 	}
 
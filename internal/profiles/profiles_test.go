@@ -368,3 +368,175 @@ func TestSave(t *testing.T) {
 		t.Errorf("Save() description = %v, want %v", profiles2.Data.Descriptions["newprofile"], "New profile")
 	}
 }
+
+func TestResolveExtends(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	data := `{
+		"profiles": {
+			"base": {
+				"API_TIMEOUT_MS": "3000000",
+				"ANTHROPIC_MODEL": "opus"
+			},
+			"china": {
+				"ANTHROPIC_BASE_URL": "https://cn.example.com",
+				"API_TIMEOUT_MS": "6000000"
+			},
+			"glm": {
+				"ANTHROPIC_MODEL": "GLM-4.6"
+			},
+			"multi": {
+				"ANTHROPIC_AUTH_TOKEN": "token"
+			}
+		},
+		"extends": {
+			"glm": "base",
+			"multi": ["base", "china"]
+		}
+	}`
+	if err := os.WriteFile(profilesPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := New(profilesPath)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	t.Run("single parent", func(t *testing.T) {
+		resolved, err := profiles.Resolve("glm")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if resolved.Env["API_TIMEOUT_MS"] != "3000000" {
+			t.Errorf("API_TIMEOUT_MS = %v, want %v", resolved.Env["API_TIMEOUT_MS"], "3000000")
+		}
+		if resolved.Env["ANTHROPIC_MODEL"] != "GLM-4.6" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want %v", resolved.Env["ANTHROPIC_MODEL"], "GLM-4.6")
+		}
+		if resolved.Sources["API_TIMEOUT_MS"] != "base" {
+			t.Errorf("Sources[API_TIMEOUT_MS] = %v, want %v", resolved.Sources["API_TIMEOUT_MS"], "base")
+		}
+		if resolved.Sources["ANTHROPIC_MODEL"] != "glm" {
+			t.Errorf("Sources[ANTHROPIC_MODEL] = %v, want %v", resolved.Sources["ANTHROPIC_MODEL"], "glm")
+		}
+		if resolved.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"] != "GLM-4.6" {
+			t.Errorf("ANTHROPIC_DEFAULT_OPUS_MODEL = %v, want %v", resolved.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"], "GLM-4.6")
+		}
+		if resolved.Sources["ANTHROPIC_DEFAULT_OPUS_MODEL"] != DefaultedSource {
+			t.Errorf("Sources[ANTHROPIC_DEFAULT_OPUS_MODEL] = %v, want %v", resolved.Sources["ANTHROPIC_DEFAULT_OPUS_MODEL"], DefaultedSource)
+		}
+	})
+
+	t.Run("multiple parents override in order", func(t *testing.T) {
+		env := profiles.Get("multi")
+		if env["API_TIMEOUT_MS"] != "6000000" {
+			t.Errorf("API_TIMEOUT_MS = %v, want %v", env["API_TIMEOUT_MS"], "6000000")
+		}
+		if env["ANTHROPIC_BASE_URL"] != "https://cn.example.com" {
+			t.Errorf("ANTHROPIC_BASE_URL = %v, want %v", env["ANTHROPIC_BASE_URL"], "https://cn.example.com")
+		}
+		if env["ANTHROPIC_MODEL"] != "opus" {
+			t.Errorf("ANTHROPIC_MODEL = %v, want %v", env["ANTHROPIC_MODEL"], "opus")
+		}
+	})
+
+	t.Run("save keeps single parent as string", func(t *testing.T) {
+		if err := profiles.Save(); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		raw, err := os.ReadFile(profilesPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config map[string]any
+		if err := json.Unmarshal(raw, &config); err != nil {
+			t.Fatal(err)
+		}
+		extends := config["extends"].(map[string]any)
+		if extends["glm"] != "base" {
+			t.Errorf("extends.glm = %v, want %v", extends["glm"], "base")
+		}
+		if list, ok := extends["multi"].([]any); !ok || len(list) != 2 {
+			t.Errorf("extends.multi = %v, want two parents", extends["multi"])
+		}
+	})
+}
+
+func TestResolveExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		extends map[string]StringList
+	}{
+		{"self reference", map[string]StringList{"a": {"a"}}},
+		{"indirect cycle", map[string]StringList{"a": {"b"}, "b": {"a"}}},
+		{"unknown parent", map[string]StringList{"a": {"missing"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Profiles: map[string]map[string]string{
+					"a": {"ANTHROPIC_MODEL": "a-model"},
+					"b": {"ANTHROPIC_MODEL": "b-model"},
+				},
+				Extends: tt.extends,
+			}
+			profiles, err := New(createTestProfilesFile(t, t.TempDir(), config))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if _, err := profiles.Resolve("a"); err == nil {
+				t.Error("Resolve() expected error, got nil")
+			}
+
+			// Get falls back to the profile's own values.
+			if env := profiles.Get("a"); env["ANTHROPIC_MODEL"] != "a-model" {
+				t.Errorf("Get() ANTHROPIC_MODEL = %v, want %v", env["ANTHROPIC_MODEL"], "a-model")
+			}
+		})
+	}
+}
+
+func TestResolveSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	profilesPath := filepath.Join(tmpDir, "profiles.json")
+	data := `{
+		"profiles": {"base": {}, "glm": {}},
+		"extends": {"glm": "base"},
+		"settings": {
+			"base": {"permissions": {"allow": ["Read"], "deny": ["WebFetch"]}, "includeCoAuthoredBy": false},
+			"glm": {"permissions": {"allow": ["Bash(go test:*)"]}, "statusLine": {"type": "command", "command": "glm-status"}}
+		}
+	}`
+	if err := os.WriteFile(profilesPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := New(profilesPath)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	resolved, err := profiles.Resolve("glm")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	permissions := resolved.Settings["permissions"].(map[string]any)
+	if allow := permissions["allow"].([]any); len(allow) != 1 || allow[0] != "Bash(go test:*)" {
+		t.Errorf("permissions.allow = %v, want the profile's own list", allow)
+	}
+	if deny := permissions["deny"].([]any); len(deny) != 1 || deny[0] != "WebFetch" {
+		t.Errorf("permissions.deny = %v, want the inherited list", deny)
+	}
+	if resolved.Settings["includeCoAuthoredBy"] != false || resolved.Settings["statusLine"] == nil {
+		t.Errorf("Settings = %v, want inherited and own keys", resolved.Settings)
+	}
+
+	// Resolving must not modify the stored settings
+	permissions["deny"] = nil
+	if profiles.Data.Settings["base"]["permissions"].(map[string]any)["deny"] == nil {
+		t.Error("Resolve() shares settings with the profile")
+	}
+}
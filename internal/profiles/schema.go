@@ -0,0 +1,107 @@
+package profiles
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/secrets"
+)
+
+// KeyType is the kind of value an environment variable holds
+type KeyType int
+
+const (
+	// KeyString accepts any value
+	KeyString KeyType = iota
+	// KeyURL accepts an absolute http or https URL
+	KeyURL
+	// KeyInt accepts a non-negative integer, such as a timeout in milliseconds
+	KeyInt
+	// KeyBool accepts 1, 0, true or false
+	KeyBool
+)
+
+// KeySpec describes a known Claude Code environment variable
+type KeySpec struct {
+	Type        KeyType
+	Description string
+}
+
+// Schema lists the environment variables understood by Claude Code
+var Schema = map[string]KeySpec{
+	"ANTHROPIC_API_KEY":                        {KeyString, "API key sent as the X-Api-Key header"},
+	"ANTHROPIC_AUTH_TOKEN":                     {KeyString, "token sent as the Authorization: Bearer header"},
+	"ANTHROPIC_BASE_URL":                       {KeyURL, "API endpoint"},
+	"ANTHROPIC_CUSTOM_HEADERS":                 {KeyString, "extra request headers, one Name: Value per line"},
+	"ANTHROPIC_MODEL":                          {KeyString, "model used by default"},
+	"ANTHROPIC_DEFAULT_HAIKU_MODEL":            {KeyString, "model used for the haiku alias"},
+	"ANTHROPIC_DEFAULT_OPUS_MODEL":             {KeyString, "model used for the opus alias"},
+	"ANTHROPIC_DEFAULT_SONNET_MODEL":           {KeyString, "model used for the sonnet alias"},
+	"ANTHROPIC_SMALL_FAST_MODEL":               {KeyString, "model used for background tasks"},
+	"API_TIMEOUT_MS":                           {KeyInt, "API request timeout in milliseconds"},
+	"BASH_DEFAULT_TIMEOUT_MS":                  {KeyInt, "default timeout for bash commands in milliseconds"},
+	"BASH_MAX_TIMEOUT_MS":                      {KeyInt, "maximum timeout for bash commands in milliseconds"},
+	"BASH_MAX_OUTPUT_LENGTH":                   {KeyInt, "maximum characters of bash output kept"},
+	"CLAUDE_CODE_API_KEY_HELPER_TTL_MS":        {KeyInt, "refresh interval for apiKeyHelper in milliseconds"},
+	"CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC": {KeyBool, "disable telemetry, error reporting and auto-updates"},
+	"CLAUDE_CODE_MAX_OUTPUT_TOKENS":            {KeyInt, "maximum output tokens per request"},
+	"CLAUDE_CODE_SUBAGENT_MODEL":               {KeyString, "model used by subagents"},
+	"CLAUDE_CODE_USE_BEDROCK":                  {KeyBool, "use Amazon Bedrock"},
+	"CLAUDE_CODE_USE_VERTEX":                   {KeyBool, "use Google Vertex AI"},
+	"DISABLE_AUTOUPDATER":                      {KeyBool, "disable automatic updates"},
+	"DISABLE_ERROR_REPORTING":                  {KeyBool, "disable error reporting"},
+	"DISABLE_NON_ESSENTIAL_MODEL_CALLS":        {KeyBool, "disable model calls for non-critical paths"},
+	"DISABLE_TELEMETRY":                        {KeyBool, "disable telemetry"},
+	"HTTP_PROXY":                               {KeyURL, "HTTP proxy server"},
+	"HTTPS_PROXY":                              {KeyURL, "HTTPS proxy server"},
+	"MAX_MCP_OUTPUT_TOKENS":                    {KeyInt, "maximum tokens in MCP tool responses"},
+	"MAX_THINKING_TOKENS":                      {KeyInt, "extended thinking budget"},
+	"MCP_TIMEOUT":                              {KeyInt, "MCP server startup timeout in milliseconds"},
+	"MCP_TOOL_TIMEOUT":                         {KeyInt, "MCP tool execution timeout in milliseconds"},
+	"NO_PROXY":                                 {KeyString, "hosts that bypass the proxy"},
+}
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateKey checks that key is a valid environment variable name
+func ValidateKey(key string) error {
+	if !envKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid environment variable name '%s'", key)
+	}
+	return nil
+}
+
+// ValidateValue checks value against the schema entry for key. Unknown keys
+// and secret references are accepted as they are.
+func ValidateValue(key, value string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	spec, ok := Schema[key]
+	if !ok || secrets.IsRef(value) {
+		return nil
+	}
+
+	switch spec.Type {
+	case KeyURL:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s must be an http or https URL, got '%s'", key, value)
+		}
+	case KeyInt:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s must be a non-negative integer, got '%s'", key, value)
+		}
+	case KeyBool:
+		switch strings.ToLower(value) {
+		case "1", "0", "true", "false":
+		default:
+			return fmt.Errorf("%s must be 1, 0, true or false, got '%s'", key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package profiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"ANTHROPIC_BASE_URL", "https://api.example.com", false},
+		{"ANTHROPIC_BASE_URL", "api.example.com", true},
+		{"ANTHROPIC_BASE_URL", "ftp://api.example.com", true},
+		{"HTTPS_PROXY", "http://127.0.0.1:7890", false},
+		{"API_TIMEOUT_MS", "600000", false},
+		{"API_TIMEOUT_MS", "10m", true},
+		{"API_TIMEOUT_MS", "-1", true},
+		{"DISABLE_TELEMETRY", "1", false},
+		{"DISABLE_TELEMETRY", "TRUE", false},
+		{"DISABLE_TELEMETRY", "yes", true},
+		{"ANTHROPIC_AUTH_TOKEN", "secret://glm/ANTHROPIC_AUTH_TOKEN", false},
+		{"MY_CUSTOM_VAR", "anything", false},
+		{"1BAD", "value", true},
+		{"BAD-KEY", "value", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			err := ValidateValue(tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateValue(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetEnv(t *testing.T) {
+	p := newTestProfiles(t)
+
+	if err := p.SetEnv("glm", map[string]string{"API_TIMEOUT_MS": "1000", "HTTPS_PROXY": "bad"}); err == nil {
+		t.Fatal("SetEnv() with an invalid value should fail")
+	}
+	if _, ok := p.Data.Profiles["glm"]["API_TIMEOUT_MS"]; ok {
+		t.Error("SetEnv() changed the profile despite an invalid value")
+	}
+
+	if err := p.SetEnv("glm", map[string]string{"API_TIMEOUT_MS": "1000"}); err != nil {
+		t.Fatalf("SetEnv() error = %v", err)
+	}
+	if got := p.Data.Profiles["glm"]["API_TIMEOUT_MS"]; got != "1000" {
+		t.Errorf("API_TIMEOUT_MS = %q, want 1000", got)
+	}
+
+	if err := p.SetEnv("missing", map[string]string{"A": "b"}); err == nil {
+		t.Error("SetEnv() on an unknown profile should fail")
+	}
+}
+
+func TestUnsetEnv(t *testing.T) {
+	p := newTestProfiles(t)
+	if err := p.SetEnv("glm", map[string]string{"API_TIMEOUT_MS": "1000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.UnsetEnv("glm", []string{"API_TIMEOUT_MS", "NOT_SET"}); err == nil {
+		t.Error("UnsetEnv() with a missing key should fail")
+	}
+	if _, ok := p.Data.Profiles["glm"]["API_TIMEOUT_MS"]; !ok {
+		t.Error("UnsetEnv() removed keys despite an error")
+	}
+
+	if err := p.SetEnv("base", map[string]string{"HTTPS_PROXY": "http://proxy:8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UnsetEnv("glm", []string{"HTTPS_PROXY"}); err == nil || !strings.Contains(err.Error(), "inherited from profile 'base'") {
+		t.Errorf("UnsetEnv() of an inherited key error = %v", err)
+	}
+
+	if err := p.UnsetEnv("glm", []string{"API_TIMEOUT_MS"}); err != nil {
+		t.Fatalf("UnsetEnv() error = %v", err)
+	}
+	if _, ok := p.Data.Profiles["glm"]["API_TIMEOUT_MS"]; ok {
+		t.Error("API_TIMEOUT_MS was not removed")
+	}
+}
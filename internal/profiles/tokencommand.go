@@ -0,0 +1,58 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultTokenTTL is how long a token from a token command is reused when
+// the profile does not set a ttl
+const DefaultTokenTTL = 5 * time.Minute
+
+// TokenCommand is a command that prints a short-lived token for a profile.
+// Claude Code calls it through apiKeyHelper instead of reading a token from
+// settings.json
+type TokenCommand struct {
+	Command string `json:"command"`
+	// TTL is how long a token is reused, as a duration such as "15m"
+	TTL string `json:"ttl,omitempty"`
+}
+
+// UnmarshalJSON accepts both "command" and {"command": ..., "ttl": ...}
+func (c *TokenCommand) UnmarshalJSON(data []byte) error {
+	var command string
+	if err := json.Unmarshal(data, &command); err == nil {
+		*c = TokenCommand{Command: command}
+		return nil
+	}
+
+	type plain TokenCommand
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("expected a command string or an object: %w", err)
+	}
+	*c = TokenCommand(v)
+	return nil
+}
+
+// MarshalJSON writes a token command without a ttl as a plain string
+func (c TokenCommand) MarshalJSON() ([]byte, error) {
+	if c.TTL == "" {
+		return json.Marshal(c.Command)
+	}
+	type plain TokenCommand
+	return json.Marshal(plain(c))
+}
+
+// CacheTTL returns how long a token is reused
+func (c TokenCommand) CacheTTL() (time.Duration, error) {
+	if c.TTL == "" {
+		return DefaultTokenTTL, nil
+	}
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid token ttl '%s': expected a positive duration such as 15m", c.TTL)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,76 @@
+package profiles
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTokenCommandJSON(t *testing.T) {
+	var commands map[string]TokenCommand
+	data := `{"a": "vault read -field=token secret/a", "b": {"command": "./token.sh", "ttl": "15m"}}`
+	if err := json.Unmarshal([]byte(data), &commands); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if commands["a"].Command != "vault read -field=token secret/a" || commands["a"].TTL != "" {
+		t.Errorf("a = %+v", commands["a"])
+	}
+	if commands["b"].Command != "./token.sh" || commands["b"].TTL != "15m" {
+		t.Errorf("b = %+v", commands["b"])
+	}
+
+	out, err := json.Marshal(commands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a":"vault read -field=token secret/a","b":{"command":"./token.sh","ttl":"15m"}}`
+	if string(out) != want {
+		t.Errorf("Marshal() = %s, want %s", out, want)
+	}
+}
+
+func TestTokenCommandCacheTTL(t *testing.T) {
+	tests := []struct {
+		ttl     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", DefaultTokenTTL, false},
+		{"15m", 15 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"0s", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := TokenCommand{Command: "x", TTL: tt.ttl}.CacheTTL()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CacheTTL(%q) = %v, %v, want %v, error %v", tt.ttl, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestResolveTokenCommand(t *testing.T) {
+	p := newTestProfiles(t)
+	p.Data.TokenCommands = map[string]TokenCommand{"base": {Command: "base-token"}}
+
+	resolved, err := p.Resolve("glm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.TokenCommand == nil || resolved.TokenCommand.Command != "base-token" {
+		t.Errorf("TokenCommand = %+v, want the inherited command", resolved.TokenCommand)
+	}
+	if issues := p.Validate("glm"); len(issues) != 0 {
+		t.Errorf("Validate() = %+v, want no missing token warning", issues)
+	}
+
+	p.Data.TokenCommands["glm"] = TokenCommand{Command: "glm-token", TTL: "never"}
+	resolved, _ = p.Resolve("glm")
+	if resolved.TokenCommand.Command != "glm-token" {
+		t.Errorf("TokenCommand = %+v, want the profile's own command", resolved.TokenCommand)
+	}
+	if issues := p.Validate("glm"); !HasErrors(issues) {
+		t.Errorf("Validate() = %+v, want an invalid ttl error", issues)
+	}
+}
@@ -0,0 +1,263 @@
+package profiles
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/output"
+	"github.com/huangdijia/ccswitch/internal/shell"
+)
+
+// Severity tells whether an issue makes a profile unusable
+type Severity int
+
+const (
+	// SeverityWarning marks a likely mistake that does not block switching
+	SeverityWarning Severity = iota
+	// SeverityError marks a profile that would produce a broken configuration
+	SeverityError
+)
+
+// String returns the label printed for the severity
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a problem found while validating a profile
+type Issue struct {
+	Profile  string
+	Key      string
+	Severity Severity
+	Message  string
+	// Fix is an actionable suggestion, usually a command to run
+	Fix string
+	// Suggestion is the known variable an unknown key probably misspells
+	Suggestion string
+
+	// value is Key's value, quoted for a shell or redacted if sensitive
+	value string
+}
+
+// String formats the issue on a single line
+func (i Issue) String() string {
+	if i.Key == "" {
+		return i.Message
+	}
+	return i.Key + ": " + i.Message
+}
+
+// Validate checks a profile against the schema of known environment variables.
+// Only the profile's own values are checked key by key; inherited values are
+// reported on the profile that defines them.
+func (p *Profiles) Validate(name string) []Issue {
+	if !p.Has(name) {
+		return []Issue{{
+			Profile:  name,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("profile '%s' not found", name),
+			Fix:      "ccswitch list",
+		}}
+	}
+
+	var issues []Issue
+	if err := p.ValidateProfile(name); err != nil {
+		issues = append(issues, Issue{
+			Profile:  name,
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Fix:      fmt.Sprintf("ccswitch edit %s", name),
+		})
+	}
+
+	for _, issue := range ValidateEnv(p.Data.Profiles[name]) {
+		issue.Profile = name
+		switch {
+		case issue.Suggestion != "":
+			issue.Fix = fmt.Sprintf("ccswitch unset %s %s && ccswitch set %s %s=%s", name, issue.Key, name, issue.Suggestion, issue.value)
+		case ValidateKey(issue.Key) == nil:
+			issue.Fix = fmt.Sprintf("ccswitch set %s %s=<value>", name, issue.Key)
+		default:
+			issue.Fix = fmt.Sprintf("ccswitch edit %s", name)
+		}
+		issues = append(issues, issue)
+	}
+
+	// model and env are written from the profile's env, not its settings
+	for _, key := range []string{"env", "model"} {
+		if _, ok := p.Data.Settings[name][key]; ok {
+			issues = append(issues, Issue{
+				Profile:  name,
+				Key:      "settings." + key,
+				Severity: SeverityError,
+				Message:  "is written from the profile's env and cannot be set in settings",
+				Fix:      fmt.Sprintf("ccswitch edit %s", name),
+			})
+		}
+	}
+
+	if command, ok := p.Data.TokenCommands[name]; ok {
+		var problem string
+		if _, err := command.CacheTTL(); err != nil {
+			problem = err.Error()
+		}
+		if command.Command == "" {
+			problem = "command is empty"
+		}
+		if problem != "" {
+			issues = append(issues, Issue{
+				Profile:  name,
+				Key:      "tokenCommand",
+				Severity: SeverityError,
+				Message:  problem,
+				Fix:      fmt.Sprintf("ccswitch edit %s", name),
+			})
+		}
+	}
+
+	issues = append(issues, p.validateToken(name)...)
+	return issues
+}
+
+// ValidateEnv checks each variable of env against the schema and reports
+// invalid values and likely misspellings of known variables
+func ValidateEnv(env map[string]string) []Issue {
+	var issues []Issue
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		value := env[key]
+		if err := ValidateValue(key, value); err != nil {
+			issues = append(issues, Issue{Key: key, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		if suggestion := SuggestKey(key); suggestion != "" {
+			// Never echo a secret into a suggested command
+			shown := "<value>"
+			if !output.IsSensitiveKey(key) && !output.IsSensitiveKey(suggestion) {
+				shown = shell.Quote(shell.Bash, value)
+			}
+			issues = append(issues, Issue{
+				Key:        key,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("unknown variable, did you mean %s?", suggestion),
+				Suggestion: suggestion,
+				value:      shown,
+			})
+		}
+	}
+	return issues
+}
+
+// ValidateAll validates every profile, in name order
+func (p *Profiles) ValidateAll() []Issue {
+	names := p.GetAll()
+	sort.Strings(names)
+
+	var issues []Issue
+	for _, name := range names {
+		issues = append(issues, p.Validate(name)...)
+	}
+	return issues
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validateToken checks that the resolved profile carries a usable token or
+// gets one from a token command
+func (p *Profiles) validateToken(name string) []Issue {
+	resolved, err := p.Resolve(name)
+	if err != nil || resolved.TokenCommand != nil {
+		return nil
+	}
+	env := resolved.Env
+	for _, key := range []string{"CLAUDE_CODE_USE_BEDROCK", "CLAUDE_CODE_USE_VERTEX"} {
+		if v := strings.ToLower(env[key]); v == "1" || v == "true" {
+			return nil
+		}
+	}
+
+	found := false
+	for _, key := range []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"} {
+		value, ok := env[key]
+		if !ok {
+			continue
+		}
+		found = true
+		if output.IsEmptyToken(value) {
+			return []Issue{{
+				Profile:  name,
+				Key:      key,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("token is empty or only the placeholder %q", value),
+				Fix:      fmt.Sprintf("ccswitch set %s --stdin %s", resolved.Sources[key], key),
+			}}
+		}
+	}
+	if !found {
+		return []Issue{{
+			Profile:  name,
+			Severity: SeverityWarning,
+			Message:  "no ANTHROPIC_AUTH_TOKEN or ANTHROPIC_API_KEY set",
+			Fix:      fmt.Sprintf("ccswitch set %s --stdin ANTHROPIC_AUTH_TOKEN", name),
+		}}
+	}
+	return nil
+}
+
+// SuggestKey returns the known variable that key is probably a misspelling
+// of, or "" if key is known or not close to any known variable
+func SuggestKey(key string) string {
+	if _, ok := Schema[key]; ok {
+		return ""
+	}
+
+	// Short names only tolerate a single typo to avoid flagging unrelated variables
+	maxDistance := 2
+	if len(key) < 10 {
+		maxDistance = 1
+	}
+
+	best, bestDistance := "", maxDistance+1
+	for known := range Schema {
+		if strings.EqualFold(key, known) {
+			return known
+		}
+		if d := editDistance(key, known); d < bestDistance || (d == bestDistance && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,80 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggestKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ANTHROPIC_BASE_ULR", "ANTHROPIC_BASE_URL"},
+		{"ANTHROPIC_AUTH_TOKN", "ANTHROPIC_AUTH_TOKEN"},
+		{"anthropic_model", "ANTHROPIC_MODEL"},
+		{"API_TIMEOUT_MS", ""},
+		{"MY_PROXY", ""},
+		{"CUSTOM_SETTING", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := SuggestKey(tt.key); got != tt.want {
+				t.Errorf("SuggestKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ccs.json")
+	data := `{
+		"default": "good",
+		"profiles": {
+			"good": {"ANTHROPIC_BASE_URL": "https://api.example.com", "ANTHROPIC_AUTH_TOKEN": "sk-real-token"},
+			"child": {"ANTHROPIC_BASE_ULR": "https://api.example.com"},
+			"placeholder": {"ANTHROPIC_AUTH_TOKEN": "sk-", "API_TIMEOUT_MS": "soon"},
+			"bedrock": {"CLAUDE_CODE_USE_BEDROCK": "1"},
+			"broken": {"ANTHROPIC_AUTH_TOKEN": "sk-real-token"}
+		},
+		"extends": {"child": "good", "broken": "missing"}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profs, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issues := profs.Validate("good"); len(issues) != 0 {
+		t.Errorf("Validate(good) = %v, want no issues", issues)
+	}
+	if issues := profs.Validate("bedrock"); len(issues) != 0 {
+		t.Errorf("Validate(bedrock) = %v, want no issues", issues)
+	}
+
+	issues := profs.Validate("child")
+	if len(issues) != 1 || issues[0].Severity != SeverityWarning || issues[0].Suggestion != "ANTHROPIC_BASE_URL" {
+		t.Fatalf("Validate(child) = %+v, want one misspelling warning", issues)
+	}
+	if !strings.Contains(issues[0].Fix, "ccswitch set child ANTHROPIC_BASE_URL='https://api.example.com'") {
+		t.Errorf("Fix = %q", issues[0].Fix)
+	}
+
+	issues = profs.Validate("placeholder")
+	if !HasErrors(issues) || len(issues) != 2 {
+		t.Errorf("Validate(placeholder) = %+v, want timeout and token errors", issues)
+	}
+
+	if issues := profs.Validate("broken"); !HasErrors(issues) {
+		t.Errorf("Validate(broken) = %+v, want an inheritance error", issues)
+	}
+
+	if issues := profs.ValidateAll(); len(issues) != 4 {
+		t.Errorf("ValidateAll() returned %d issues, want 4: %+v", len(issues), issues)
+	}
+}
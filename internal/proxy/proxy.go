@@ -0,0 +1,288 @@
+// Package proxy implements a local Anthropic-compatible proxy that forwards
+// requests to the upstream of the active profile
+package proxy
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/probe"
+	"github.com/huangdijia/ccswitch/internal/profiles"
+)
+
+// DefaultAddress is the address the proxy listens on by default
+const DefaultAddress = "127.0.0.1:8787"
+
+// hopHeaders are connection-specific and never forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Upstream is the profile requests are forwarded to
+type Upstream struct {
+	Name string
+	// Env is the profile's environment with secrets resolved
+	Env      map[string]string
+	ModelMap profiles.ModelMap
+}
+
+// UpstreamFunc returns the active upstream. It is called for every request
+type UpstreamFunc func() (*Upstream, error)
+
+// Server forwards Anthropic API requests to the active profile's upstream
+type Server struct {
+	Upstream UpstreamFunc
+	// Token is the credential clients must send, as a bearer token or API
+	// key. Requests without it are rejected, so that other local users and
+	// web pages cannot spend the profile's credentials
+	Token  string
+	Client *http.Client
+	Logger *log.Logger
+}
+
+// ClientEnv returns the environment that points a client at the proxy: the
+// profile's settings without credentials and model names, which the proxy
+// supplies per request, and the proxy's token in place of the credentials
+func ClientEnv(env map[string]string, state *State) map[string]string {
+	clientEnv := make(map[string]string, len(env))
+	for k, v := range env {
+		clientEnv[k] = v
+	}
+	for _, key := range profiles.ModelKeys {
+		delete(clientEnv, key)
+	}
+	delete(clientEnv, "ANTHROPIC_API_KEY")
+	clientEnv["ANTHROPIC_BASE_URL"] = state.URL()
+	clientEnv["ANTHROPIC_AUTH_TOKEN"] = state.Token
+	return clientEnv
+}
+
+// MapModel maps a model requested by a client to the upstream model: the
+// first matching modelMap rule, or the profile's model of the same family.
+// Models are passed through unchanged when the profile does not configure one
+func MapModel(model string, env map[string]string, modelMap profiles.ModelMap) string {
+	if mapped, ok := modelMap.Lookup(model); ok {
+		return mapped
+	}
+	for _, key := range profiles.ModelKeys {
+		if env[key] == model {
+			return model
+		}
+	}
+
+	var keys []string
+	lower := strings.ToLower(model)
+	switch {
+	case strings.Contains(lower, "haiku"):
+		keys = []string{"ANTHROPIC_DEFAULT_HAIKU_MODEL", "ANTHROPIC_SMALL_FAST_MODEL"}
+	case strings.Contains(lower, "opus"):
+		keys = []string{"ANTHROPIC_DEFAULT_OPUS_MODEL"}
+	case strings.Contains(lower, "sonnet"):
+		keys = []string{"ANTHROPIC_DEFAULT_SONNET_MODEL"}
+	}
+	keys = append(keys, "ANTHROPIC_MODEL")
+
+	for _, key := range keys {
+		if mapped := env[key]; mapped != "" {
+			return mapped
+		}
+	}
+	return model
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	// Browsers send the name a page was loaded from, so a non-loopback Host
+	// means a page rebinding its DNS name to this address
+	if !IsLoopback(hostname(r.Host)) {
+		s.reject(w, http.StatusForbidden, "permission_error", fmt.Sprintf("host %s is not allowed", r.Host))
+		return
+	}
+	if !s.authorized(r) {
+		s.reject(w, http.StatusUnauthorized, "authentication_error", "invalid proxy token")
+		return
+	}
+
+	upstream, err := s.Upstream()
+	if err != nil {
+		s.fail(w, http.StatusBadGateway, err)
+		return
+	}
+	name := upstream.Name
+
+	target := probe.TargetFromEnv(upstream.Env)
+	upstreamURL, err := url.Parse(target.BaseURL)
+	if err != nil {
+		s.fail(w, http.StatusBadGateway, fmt.Errorf("invalid base URL for profile %s: %w", name, err))
+		return
+	}
+	upstreamURL.Path = strings.TrimRight(upstreamURL.Path, "/") + r.URL.Path
+	upstreamURL.RawPath = ""
+	upstreamURL.RawQuery = r.URL.RawQuery
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	body, model := rewriteModel(body, upstream)
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, upstreamURL.String(), bytes.NewReader(body))
+	if err != nil {
+		s.fail(w, http.StatusBadGateway, err)
+		return
+	}
+	req.Header = r.Header.Clone()
+	removeHopHeaders(req.Header)
+	req.Header.Del("Authorization")
+	req.Header.Del("X-Api-Key")
+	target.Authorize(req.Header)
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		s.fail(w, http.StatusBadGateway, fmt.Errorf("upstream %s: %w", name, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	header := w.Header()
+	for k, values := range resp.Header {
+		header[k] = values
+	}
+	removeHopHeaders(header)
+	w.WriteHeader(resp.StatusCode)
+	copyFlushing(w, resp.Body)
+
+	s.logf("%s %s %s model=%s -> %d (%dms)", name, r.Method, r.URL.Path, model, resp.StatusCode, time.Since(start).Milliseconds())
+}
+
+// authorized reports whether r carries the proxy's token
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token == "" {
+		return false
+	}
+	token := r.Header.Get("X-Api-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+// IsLoopback reports whether host, a host name or IP address without port,
+// refers to the local machine only
+func IsLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// hostname strips the port from a host[:port] value
+func hostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return strings.Trim(hostport, "[]")
+}
+
+func (s *Server) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+// fail writes an error in the Anthropic API error format
+func (s *Server) fail(w http.ResponseWriter, status int, err error) {
+	s.reject(w, status, "api_error", err.Error())
+}
+
+// reject writes an error of the given Anthropic API error type
+func (s *Server) reject(w http.ResponseWriter, status int, errType, message string) {
+	s.logf("error: %s", message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"type": "error",
+		"error": map[string]string{
+			"type":    errType,
+			"message": "ccswitch proxy: " + message,
+		},
+	})
+}
+
+// rewriteModel replaces the model of a JSON request body with the upstream's
+// model, returning the body unchanged when it has no model
+func rewriteModel(body []byte, upstream *Upstream) ([]byte, string) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return body, ""
+	}
+	var model string
+	if err := json.Unmarshal(payload["model"], &model); err != nil || model == "" {
+		return body, ""
+	}
+
+	mapped := MapModel(model, upstream.Env, upstream.ModelMap)
+	if mapped == model {
+		return body, model
+	}
+	payload["model"], _ = json.Marshal(mapped)
+	rewritten, err := json.Marshal(payload)
+	if err != nil {
+		return body, model
+	}
+	return rewritten, mapped
+}
+
+// copyFlushing copies the response body, flushing after every read so that
+// server-sent events reach the client as they arrive
+func copyFlushing(w http.ResponseWriter, body io.Reader) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, k := range hopHeaders {
+		h.Del(k)
+	}
+}
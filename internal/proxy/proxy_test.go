@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/profiles"
+)
+
+func TestMapModel(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_MODEL":               "glm-4.6",
+		"ANTHROPIC_SMALL_FAST_MODEL":    "glm-4.5-air",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL": "glm-4.5-air",
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":  "glm-4.6-opus",
+	}
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"claude-3-5-haiku-20241022", "glm-4.5-air"},
+		{"claude-opus-4-1", "glm-4.6-opus"},
+		{"claude-sonnet-4-5", "glm-4.6"},
+		{"some-other-model", "glm-4.6"},
+		{"glm-4.5-air", "glm-4.5-air"},
+		{"claude-opus-4-1-20250805", "GLM-4.6"},
+	}
+	modelMap := profiles.ModelMap{{Pattern: "claude-opus-4-1-*", Model: "GLM-4.6"}}
+	for _, tt := range tests {
+		if got := MapModel(tt.model, env, modelMap); got != tt.want {
+			t.Errorf("MapModel(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+
+	if got := MapModel("claude-sonnet-4-5", map[string]string{}, nil); got != "claude-sonnet-4-5" {
+		t.Errorf("MapModel() without models = %v, want passthrough", got)
+	}
+}
+
+func TestClientEnv(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_BASE_URL":   "https://upstream",
+		"ANTHROPIC_AUTH_TOKEN": "secret",
+		"ANTHROPIC_API_KEY":    "key",
+		"ANTHROPIC_MODEL":      "m",
+		"API_TIMEOUT_MS":       "1000",
+	}
+	got := ClientEnv(env, &State{Address: "127.0.0.1:9999", Token: "proxy-token"})
+
+	if got["ANTHROPIC_BASE_URL"] != "http://127.0.0.1:9999" || got["ANTHROPIC_AUTH_TOKEN"] != "proxy-token" {
+		t.Errorf("ClientEnv() = %v, want proxy URL and token", got)
+	}
+	for _, key := range []string{"ANTHROPIC_API_KEY", "ANTHROPIC_MODEL"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("ClientEnv() kept %s", key)
+		}
+	}
+	if got["API_TIMEOUT_MS"] != "1000" {
+		t.Errorf("ClientEnv() dropped API_TIMEOUT_MS")
+	}
+	if env["ANTHROPIC_AUTH_TOKEN"] != "secret" {
+		t.Error("ClientEnv() modified its input")
+	}
+}
+
+func TestServer(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Auth", r.Header.Get("Authorization")+"|"+r.Header.Get("x-api-key"))
+		w.Header().Set("X-Model", body.Model)
+		if !body.Stream {
+			w.Write([]byte(`{"type":"message"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "event: ping\ndata: {\"n\":%d}\n\n", i)
+			flusher.Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}))
+	defer upstream.Close()
+
+	upstreams := map[string]map[string]string{
+		"a": {"ANTHROPIC_BASE_URL": upstream.URL + "/api/anthropic/", "ANTHROPIC_AUTH_TOKEN": "token-a", "ANTHROPIC_MODEL": "model-a"},
+		"b": {"ANTHROPIC_BASE_URL": upstream.URL, "ANTHROPIC_API_KEY": "key-b", "ANTHROPIC_MODEL": "model-b"},
+	}
+	active := "a"
+	const token = "proxy-token"
+	server := httptest.NewServer(&Server{Token: token, Upstream: func() (*Upstream, error) {
+		return &Upstream{Name: active, Env: upstreams[active]}, nil
+	}})
+	defer server.Close()
+
+	send := func(t *testing.T, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/messages?beta=true", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("forwards with profile credentials", func(t *testing.T) {
+		resp := send(t, `{"model":"claude-sonnet-4-5","max_tokens":1}`)
+		defer resp.Body.Close()
+		if got := resp.Header.Get("X-Path"); got != "/api/anthropic/v1/messages" {
+			t.Errorf("upstream path = %v", got)
+		}
+		if got := resp.Header.Get("X-Auth"); got != "Bearer token-a|" {
+			t.Errorf("upstream auth = %v", got)
+		}
+		if got := resp.Header.Get("X-Model"); got != "model-a" {
+			t.Errorf("upstream model = %v, want model-a", got)
+		}
+	})
+
+	t.Run("switches upstream without restart", func(t *testing.T) {
+		active = "b"
+		defer func() { active = "a" }()
+		resp := send(t, `{"model":"claude-sonnet-4-5","max_tokens":1}`)
+		defer resp.Body.Close()
+		if got := resp.Header.Get("X-Auth"); got != "|key-b" {
+			t.Errorf("upstream auth = %v", got)
+		}
+		if got := resp.Header.Get("X-Model"); got != "model-b" {
+			t.Errorf("upstream model = %v, want model-b", got)
+		}
+	})
+
+	t.Run("streams server-sent events", func(t *testing.T) {
+		resp := send(t, `{"model":"claude-sonnet-4-5","stream":true}`)
+		defer resp.Body.Close()
+		if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("Content-Type = %v", got)
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		if err != nil || line != "event: ping\n" {
+			t.Fatalf("first event line = %q, %v", line, err)
+		}
+		rest, _ := io.ReadAll(reader)
+		if n := strings.Count(string(rest), "event: ping"); n != 2 {
+			t.Errorf("received %d more events, want 2", n)
+		}
+	})
+
+	t.Run("upstream errors", func(t *testing.T) {
+		failing := httptest.NewServer(&Server{Token: token, Upstream: func() (*Upstream, error) {
+			return nil, fmt.Errorf("no active profile")
+		}})
+		defer failing.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, failing.URL+"/v1/messages", strings.NewReader(`{}`))
+		req.Header.Set("X-Api-Key", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("status = %v, want %v", resp.StatusCode, http.StatusBadGateway)
+		}
+	})
+
+	t.Run("rejects requests without the token or from other hosts", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			auth   string
+			host   string
+			status int
+		}{
+			{"missing token", "", "", http.StatusUnauthorized},
+			{"wrong token", "Bearer other", "", http.StatusUnauthorized},
+			{"rebound host name", "Bearer " + token, "attacker.example:8787", http.StatusForbidden},
+			{"localhost", "Bearer " + token, "localhost:8787", http.StatusOK},
+		}
+		for _, tt := range tests {
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/messages", strings.NewReader(`{}`))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("%s: status = %v, want %v", tt.name, resp.StatusCode, tt.status)
+			}
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+)
+
+// State records a running proxy and the profile it forwards to. It is shared
+// through a file so that `use` can redirect a running proxy
+type State struct {
+	Address string `json:"address"`
+	Profile string `json:"profile"`
+	PID     int    `json:"pid"`
+	// Token is the credential clients of this run must send, see NewToken
+	Token string `json:"token"`
+}
+
+// NewToken returns a random token for one run of the proxy
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "ccswitch-proxy-" + hex.EncodeToString(b), nil
+}
+
+// StatePath returns the proxy state file stored next to the profiles file
+func StatePath(profilesPath string) string {
+	return filepath.Join(filepath.Dir(profilesPath), "proxy.json")
+}
+
+// LoadState reads the state file, returning nil when it does not exist
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse proxy state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save replaces the state file atomically, so the running proxy never reads
+// a partly written file. The file is private because it holds the token
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFileNoBackup(path, data, 0600)
+}
+
+// URL returns the base URL clients use to reach the proxy
+func (s *State) URL() string {
+	return "http://" + s.Address
+}
+
+// Running returns the state of a proxy that is accepting connections, or nil
+// when no proxy is running. Stale state files are ignored
+func Running(path string) (*State, error) {
+	state, err := LoadState(path)
+	if err != nil || state == nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", state.Address, 200*time.Millisecond)
+	if err != nil {
+		return nil, nil
+	}
+	conn.Close()
+	return state, nil
+}
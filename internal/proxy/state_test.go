@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	path := StatePath(filepath.Join(t.TempDir(), "ccs.json"))
+
+	if state, err := LoadState(path); err != nil || state != nil {
+		t.Fatalf("LoadState() without file = %v, %v, want nil", state, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &State{Address: listener.Addr().String(), Profile: "glm", PID: 1}
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	running, err := Running(path)
+	if err != nil || running == nil {
+		t.Fatalf("Running() = %v, %v, want state", running, err)
+	}
+	if running.Profile != "glm" {
+		t.Errorf("Running().Profile = %v, want glm", running.Profile)
+	}
+
+	listener.Close()
+	if running, err := Running(path); err != nil || running != nil {
+		t.Errorf("Running() after close = %v, %v, want nil", running, err)
+	}
+}
@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RefPrefix marks a profile value as a reference into a secret store
+const RefPrefix = "secret://"
+
+const (
+	// BackendVault stores secrets in a passphrase-encrypted local file
+	BackendVault = "vault"
+	// BackendSecretService stores secrets in the freedesktop Secret Service (GNOME Keyring, KWallet)
+	BackendSecretService = "secret-service"
+)
+
+// Keys lists the profile keys whose values are kept in the secret store
+var Keys = []string{
+	"ANTHROPIC_AUTH_TOKEN",
+	"ANTHROPIC_API_KEY",
+}
+
+// ErrNotFound is returned when a secret does not exist in the store
+var ErrNotFound = errors.New("secret not found")
+
+// Store is a backend that holds secret values by name
+type Store interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// Name returns the store name used for a profile key
+func Name(profile, key string) string {
+	return profile + "/" + key
+}
+
+// Ref returns the reference written to ccs.json for a stored secret
+func Ref(name string) string {
+	return RefPrefix + name
+}
+
+// IsRef reports whether a value is a secret reference
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// IsKey reports whether a profile key should be kept in the secret store
+func IsKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRefs reports whether any value in env is a secret reference
+func HasRefs(env map[string]string) bool {
+	for _, v := range env {
+		if IsRef(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns a copy of env with every secret reference replaced by its value
+func Resolve(store Store, env map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		if IsRef(v) {
+			name := strings.TrimPrefix(v, RefPrefix)
+			secret, err := store.Get(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s for %s: %w", v, k, err)
+			}
+			v = secret
+		}
+		result[k] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"testing"
+)
+
+// memoryStore is an in-memory Store used by tests
+type memoryStore map[string]string
+
+func (m memoryStore) Get(name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (m memoryStore) Set(name, value string) error {
+	m[name] = value
+	return nil
+}
+
+func (m memoryStore) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+func TestRef(t *testing.T) {
+	ref := Ref(Name("glm", "ANTHROPIC_AUTH_TOKEN"))
+	if ref != "secret://glm/ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("Ref() = %v, want %v", ref, "secret://glm/ANTHROPIC_AUTH_TOKEN")
+	}
+	if !IsRef(ref) {
+		t.Errorf("IsRef(%q) = false, want true", ref)
+	}
+	if IsRef("sk-plain") {
+		t.Error("IsRef(\"sk-plain\") = true, want false")
+	}
+}
+
+func TestIsKey(t *testing.T) {
+	if !IsKey("ANTHROPIC_AUTH_TOKEN") || !IsKey("ANTHROPIC_API_KEY") {
+		t.Error("IsKey() should accept token keys")
+	}
+	if IsKey("ANTHROPIC_BASE_URL") {
+		t.Error("IsKey(\"ANTHROPIC_BASE_URL\") = true, want false")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	store := memoryStore{"glm/ANTHROPIC_AUTH_TOKEN": "sk-secret"}
+
+	t.Run("replaces references", func(t *testing.T) {
+		env := map[string]string{
+			"ANTHROPIC_AUTH_TOKEN": "secret://glm/ANTHROPIC_AUTH_TOKEN",
+			"ANTHROPIC_BASE_URL":   "https://api.test.com",
+		}
+		got, err := Resolve(store, env)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if got["ANTHROPIC_AUTH_TOKEN"] != "sk-secret" {
+			t.Errorf("ANTHROPIC_AUTH_TOKEN = %v, want %v", got["ANTHROPIC_AUTH_TOKEN"], "sk-secret")
+		}
+		if got["ANTHROPIC_BASE_URL"] != "https://api.test.com" {
+			t.Errorf("ANTHROPIC_BASE_URL = %v, want %v", got["ANTHROPIC_BASE_URL"], "https://api.test.com")
+		}
+		if env["ANTHROPIC_AUTH_TOKEN"] != "secret://glm/ANTHROPIC_AUTH_TOKEN" {
+			t.Error("Resolve() should not modify its input")
+		}
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		env := map[string]string{"ANTHROPIC_AUTH_TOKEN": "secret://missing"}
+		if _, err := Resolve(store, env); err == nil {
+			t.Error("Resolve() expected error for missing secret, got nil")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretServiceApp is the attribute value used to group ccswitch secrets
+const secretServiceApp = "ccswitch"
+
+// SecretService stores secrets in the freedesktop Secret Service through the
+// secret-tool command shipped with libsecret
+type SecretService struct {
+	// run executes secret-tool with the given stdin and arguments
+	run func(stdin string, args ...string) (string, error)
+}
+
+// NewSecretService returns a Secret Service backend, failing when secret-tool is not installed
+func NewSecretService() (*SecretService, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("secret-tool not found (install libsecret-tools): %w", err)
+	}
+
+	return &SecretService{
+		run: func(stdin string, args ...string) (string, error) {
+			cmd := exec.Command(path, args...)
+			cmd.Stdin = strings.NewReader(stdin)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				toolErr := &toolError{command: args[0], stderr: strings.TrimSpace(stderr.String()), code: -1, err: err}
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					toolErr.code = exitErr.ExitCode()
+				}
+				return "", toolErr
+			}
+			return stdout.String(), nil
+		},
+	}, nil
+}
+
+// Get looks up the secret stored under name. Failures other than a missing
+// secret, such as a locked keyring, are returned with secret-tool's message
+func (s *SecretService) Get(name string) (string, error) {
+	out, err := s.run("", "lookup", "application", secretServiceApp, "name", name)
+	if err != nil {
+		// secret-tool exits with status 1 and no message when nothing matches
+		var toolErr *toolError
+		if errors.As(err, &toolErr) && toolErr.code == 1 && toolErr.stderr == "" {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to look up secret '%s': %w", name, err)
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// toolError is a failed secret-tool run
+type toolError struct {
+	command string
+	stderr  string
+	// code is the exit status, -1 when secret-tool did not run
+	code int
+	err  error
+}
+
+func (e *toolError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("secret-tool %s: %s (%v)", e.command, e.stderr, e.err)
+	}
+	return fmt.Sprintf("secret-tool %s: %v", e.command, e.err)
+}
+
+func (e *toolError) Unwrap() error {
+	return e.err
+}
+
+// Set stores value under name, replacing any existing secret
+func (s *SecretService) Set(name, value string) error {
+	_, err := s.run(value, "store", "--label", "ccswitch: "+name, "application", secretServiceApp, "name", name)
+	return err
+}
+
+// Delete removes the secret stored under name
+func (s *SecretService) Delete(name string) error {
+	_, err := s.run("", "clear", "application", secretServiceApp, "name", name)
+	return err
+}
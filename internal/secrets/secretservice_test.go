@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool emulates secret-tool with an in-memory keyring
+func fakeSecretTool(keyring map[string]string) *SecretService {
+	return &SecretService{
+		run: func(stdin string, args ...string) (string, error) {
+			name := args[len(args)-1]
+			switch args[0] {
+			case "store":
+				keyring[name] = stdin
+				return "", nil
+			case "lookup":
+				if name == "locked" {
+					return "", &toolError{command: "lookup", stderr: "Cannot unlock the keyring", code: 1, err: errors.New("exit status 1")}
+				}
+				v, ok := keyring[name]
+				if !ok {
+					return "", &toolError{command: "lookup", code: 1, err: errors.New("exit status 1")}
+				}
+				return v, nil
+			case "clear":
+				delete(keyring, name)
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+}
+
+func TestSecretService(t *testing.T) {
+	keyring := make(map[string]string)
+	store := fakeSecretTool(keyring)
+
+	if err := store.Set("glm/ANTHROPIC_AUTH_TOKEN", "sk-secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if keyring["glm/ANTHROPIC_AUTH_TOKEN"] != "sk-secret" {
+		t.Errorf("keyring value = %v, want %v", keyring["glm/ANTHROPIC_AUTH_TOKEN"], "sk-secret")
+	}
+
+	got, err := store.Get("glm/ANTHROPIC_AUTH_TOKEN")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "sk-secret" {
+		t.Errorf("Get() = %v, want %v", got, "sk-secret")
+	}
+
+	if err := store.Delete("glm/ANTHROPIC_AUTH_TOKEN"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get("glm/ANTHROPIC_AUTH_TOKEN"); err != ErrNotFound {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+
+	_, err = store.Get("locked")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "Cannot unlock the keyring") {
+		t.Errorf("Get() on a locked keyring error = %v, want secret-tool's message", err)
+	}
+
+	missing := &SecretService{run: func(string, ...string) (string, error) {
+		return "", &toolError{command: "lookup", code: -1, err: errors.New("executable file not found")}
+	}}
+	if _, err := missing.Get("glm/ANTHROPIC_AUTH_TOKEN"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() without secret-tool error = %v, want a failure other than %v", err, ErrNotFound)
+	}
+}
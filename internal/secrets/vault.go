@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+	"github.com/huangdijia/ccswitch/internal/pathutil"
+)
+
+const (
+	vaultVersion = 1
+	vaultCheck   = "ccswitch"
+)
+
+// vaultIterations is the PBKDF2 work factor used for new vaults
+var vaultIterations = 600000
+
+// ErrWrongPassphrase is returned when a vault cannot be decrypted with the given passphrase
+var ErrWrongPassphrase = errors.New("wrong vault passphrase")
+
+// vaultFile is the on-disk format of the vault
+type vaultFile struct {
+	Version    int               `json:"version"`
+	Iterations int               `json:"iterations"`
+	Salt       string            `json:"salt"`
+	Check      string            `json:"check"`
+	Secrets    map[string]string `json:"secrets"`
+}
+
+// Vault is a local secret store encrypted with a key derived from a passphrase.
+// Each secret is sealed separately with AES-256-GCM; the key is derived with
+// PBKDF2-SHA256 from the passphrase and a random per-vault salt.
+type Vault struct {
+	Path string
+	key  []byte
+	data *vaultFile
+}
+
+// OpenVault opens the vault at path, creating a new empty vault in memory if
+// the file does not exist yet
+func OpenVault(path, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("vault passphrase cannot be empty")
+	}
+
+	v := &Vault{Path: path}
+
+	if !pathutil.FileExists(path) {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		v.data = &vaultFile{
+			Version:    vaultVersion,
+			Iterations: vaultIterations,
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+			Secrets:    make(map[string]string),
+		}
+		if err := v.deriveKey(passphrase); err != nil {
+			return nil, err
+		}
+		check, err := v.seal(vaultCheck)
+		if err != nil {
+			return nil, err
+		}
+		v.data.Check = check
+		return v, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v.data = &vaultFile{}
+	if err := json.Unmarshal(raw, v.data); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	if v.data.Secrets == nil {
+		v.data.Secrets = make(map[string]string)
+	}
+	if err := v.deriveKey(passphrase); err != nil {
+		return nil, err
+	}
+	if check, err := v.open(v.data.Check); err != nil || check != vaultCheck {
+		return nil, ErrWrongPassphrase
+	}
+
+	return v, nil
+}
+
+// Get returns the decrypted secret stored under name
+func (v *Vault) Get(name string) (string, error) {
+	sealed, ok := v.data.Secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v.open(sealed)
+}
+
+// Set encrypts value, stores it under name and writes the vault
+func (v *Vault) Set(name, value string) error {
+	sealed, err := v.seal(value)
+	if err != nil {
+		return err
+	}
+	v.data.Secrets[name] = sealed
+	return v.save()
+}
+
+// Delete removes the secret stored under name and writes the vault
+func (v *Vault) Delete(name string) error {
+	if _, ok := v.data.Secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(v.data.Secrets, name)
+	return v.save()
+}
+
+func (v *Vault) deriveKey(passphrase string) error {
+	salt, err := base64.StdEncoding.DecodeString(v.data.Salt)
+	if err != nil {
+		return fmt.Errorf("invalid vault salt: %w", err)
+	}
+	v.key, err = pbkdf2.Key(sha256.New, passphrase, salt, v.data.Iterations, 32)
+	return err
+}
+
+func (v *Vault) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(v.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and returns base64(nonce || ciphertext)
+func (v *Vault) seal(plaintext string) (string, error) {
+	gcm, err := v.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value produced by seal
+func (v *Vault) open(encoded string) (string, error) {
+	gcm, err := v.aead()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid sealed value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (v *Vault) save() error {
+	data, err := json.MarshalIndent(v.data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+	if err := pathutil.EnsureDir(filepath.Dir(v.Path), 0700); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(v.Path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func init() {
+	// Keep key derivation fast in tests.
+	vaultIterations = 1000
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), "vault.json")
+
+	vault, err := OpenVault(vaultPath, "passphrase")
+	if err != nil {
+		t.Fatalf("OpenVault() error = %v", err)
+	}
+	if err := vault.Set("glm/ANTHROPIC_AUTH_TOKEN", "sk-very-secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := os.ReadFile(vaultPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "sk-very-secret") {
+		t.Error("vault file contains the plaintext secret")
+	}
+	if info, err := os.Stat(vaultPath); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("vault file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	reopened, err := OpenVault(vaultPath, "passphrase")
+	if err != nil {
+		t.Fatalf("OpenVault() reopen error = %v", err)
+	}
+	got, err := reopened.Get("glm/ANTHROPIC_AUTH_TOKEN")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "sk-very-secret" {
+		t.Errorf("Get() = %v, want %v", got, "sk-very-secret")
+	}
+
+	if err := reopened.Delete("glm/ANTHROPIC_AUTH_TOKEN"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := reopened.Get("glm/ANTHROPIC_AUTH_TOKEN"); err != ErrNotFound {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestVaultWrongPassphrase(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), "vault.json")
+
+	vault, err := OpenVault(vaultPath, "right")
+	if err != nil {
+		t.Fatalf("OpenVault() error = %v", err)
+	}
+	if err := vault.Set("name", "value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if _, err := OpenVault(vaultPath, "wrong"); err != ErrWrongPassphrase {
+		t.Errorf("OpenVault() error = %v, want %v", err, ErrWrongPassphrase)
+	}
+}
+
+func TestVaultEmptyPassphrase(t *testing.T) {
+	if _, err := OpenVault(filepath.Join(t.TempDir(), "vault.json"), ""); err == nil {
+		t.Error("OpenVault() expected error for empty passphrase, got nil")
+	}
+}
@@ -0,0 +1,138 @@
+package settings
+
+import "reflect"
+
+// managedKeys are the top-level keys written through the Model and Env fields,
+// which Merge and Unmerge leave alone
+var managedKeys = []string{"model", "env"}
+
+// Merge deep-merges values into the settings. Nested objects are merged key
+// by key; any other value replaces the existing one. The "model" and "env"
+// keys are managed through their fields and are skipped. It returns the
+// replaced values, to be passed to Unmerge.
+func (s *ClaudeSettings) Merge(values map[string]any) map[string]any {
+	if s.raw == nil {
+		s.raw = make(map[string]any)
+	}
+	values = withoutManagedKeys(values)
+	replaced := ReplacedValues(s.raw, values)
+	DeepMerge(s.raw, values)
+	return replaced
+}
+
+// Unmerge removes the values previously merged with Merge, as long as the
+// settings still hold them unchanged, and puts back the values the merge
+// replaced. Objects left empty are removed too.
+func (s *ClaudeSettings) Unmerge(values, replaced map[string]any) {
+	if s.raw == nil {
+		return
+	}
+	DeepRestore(s.raw, withoutManagedKeys(values), replaced)
+}
+
+func withoutManagedKeys(values map[string]any) map[string]any {
+	result := make(map[string]any, len(values))
+	for k, v := range values {
+		result[k] = v
+	}
+	for _, k := range managedKeys {
+		delete(result, k)
+	}
+	return result
+}
+
+// DeepMerge merges src into dst and returns dst, allocating it if nil.
+// Nested objects are merged recursively; other values from src replace those
+// in dst. Values are copied, so dst never shares objects or arrays with src.
+func DeepMerge(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			dst[k] = DeepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = cloneValue(v)
+	}
+	return dst
+}
+
+// ReplacedValues returns the values of dst that merging src with DeepMerge
+// replaces, shaped like src. Arrays are replaced as a whole
+func ReplacedValues(dst, src map[string]any) map[string]any {
+	replaced := make(map[string]any)
+	for k, v := range src {
+		current, ok := dst[k]
+		if !ok {
+			continue
+		}
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := current.(map[string]any)
+		if srcIsMap && dstIsMap {
+			if nested := ReplacedValues(dstMap, srcMap); len(nested) > 0 {
+				replaced[k] = nested
+			}
+			continue
+		}
+		replaced[k] = cloneValue(current)
+	}
+	return replaced
+}
+
+// DeepRemove removes from dst every value of src that dst holds unchanged,
+// recursing into nested objects and dropping objects left empty
+func DeepRemove(dst, src map[string]any) {
+	DeepRestore(dst, src, nil)
+}
+
+// DeepRestore is DeepRemove that puts back the value of replaced at the path
+// of each removed value, undoing a DeepMerge whose replaced values were
+// recorded with ReplacedValues. Values changed since the merge are kept
+func DeepRestore(dst, src, replaced map[string]any) {
+	for k, v := range src {
+		current, ok := dst[k]
+		if !ok {
+			continue
+		}
+		old, wasReplaced := replaced[k]
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := current.(map[string]any)
+		if srcIsMap && dstIsMap {
+			oldMap, oldIsMap := old.(map[string]any)
+			DeepRestore(dstMap, srcMap, oldMap)
+			if len(dstMap) == 0 {
+				if wasReplaced && !oldIsMap {
+					dst[k] = cloneValue(old)
+				} else {
+					delete(dst, k)
+				}
+			}
+			continue
+		}
+		if reflect.DeepEqual(current, v) {
+			if wasReplaced {
+				dst[k] = cloneValue(old)
+			} else {
+				delete(dst, k)
+			}
+		}
+	}
+}
+
+// cloneValue deep-copies JSON objects and arrays
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return DeepMerge(nil, v)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = cloneValue(item)
+		}
+		return result
+	}
+	return v
+}
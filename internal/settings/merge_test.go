@@ -0,0 +1,107 @@
+package settings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDeepMerge(t *testing.T) {
+	dst := decode(t, `{"permissions": {"allow": ["Read"], "deny": ["WebFetch"]}, "theme": "dark"}`)
+	src := decode(t, `{"permissions": {"allow": ["Bash(go test:*)"]}, "includeCoAuthoredBy": false}`)
+
+	got := DeepMerge(dst, src)
+	want := decode(t, `{"permissions": {"allow": ["Bash(go test:*)"], "deny": ["WebFetch"]}, "theme": "dark", "includeCoAuthoredBy": false}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeepMerge() = %v, want %v", got, want)
+	}
+
+	// The result must not share arrays with src
+	got["permissions"].(map[string]any)["allow"].([]any)[0] = "changed"
+	if src["permissions"].(map[string]any)["allow"].([]any)[0] != "Bash(go test:*)" {
+		t.Error("DeepMerge() result shares values with src")
+	}
+}
+
+func TestDeepRemove(t *testing.T) {
+	dst := decode(t, `{"permissions": {"allow": ["Read"], "deny": ["WebFetch"]}, "statusLine": {"type": "command"}, "theme": "light"}`)
+	src := decode(t, `{"permissions": {"allow": ["Read"]}, "statusLine": {"type": "command"}, "theme": "dark"}`)
+
+	DeepRemove(dst, src)
+	want := decode(t, `{"permissions": {"deny": ["WebFetch"]}, "theme": "light"}`)
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("DeepRemove() = %v, want %v", dst, want)
+	}
+}
+
+func TestDeepRestore(t *testing.T) {
+	user := `{"permissions": {"allow": ["Read"], "deny": ["WebFetch"]}, "theme": "light", "statusLine": "off", "model": "opus"}`
+	dst := decode(t, user)
+	src := decode(t, `{"permissions": {"allow": ["Bash(go test:*)"], "ask": ["Write"]}, "theme": "light", "statusLine": {"type": "command"}, "cleanupPeriodDays": 7}`)
+
+	replaced := ReplacedValues(dst, src)
+	wantReplaced := decode(t, `{"permissions": {"allow": ["Read"]}, "theme": "light", "statusLine": "off"}`)
+	if !reflect.DeepEqual(replaced, wantReplaced) {
+		t.Errorf("ReplacedValues() = %v, want %v", replaced, wantReplaced)
+	}
+
+	DeepMerge(dst, src)
+	DeepRestore(dst, src, replaced)
+	if want := decode(t, user); !reflect.DeepEqual(dst, want) {
+		t.Errorf("DeepRestore() = %v, want the user's settings %v", dst, want)
+	}
+
+	// Values changed after the merge are kept
+	DeepMerge(dst, src)
+	dst["theme"] = "dark"
+	DeepRestore(dst, src, replaced)
+	if dst["theme"] != "dark" {
+		t.Errorf("theme = %v, want the value changed after the merge", dst["theme"])
+	}
+}
+
+func TestMergeAndUnmerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(`{"theme": "dark", "env": {"A": "1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := decode(t, `{"apiKeyHelper": "/bin/token", "env": {"B": "2"}, "model": "opus"}`)
+	replaced := s.Merge(values)
+	if err := s.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	got := decode(t, string(data))
+	want := decode(t, `{"theme": "dark", "apiKeyHelper": "/bin/token", "env": {"A": "1"}}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after Merge() = %v, want %v", got, want)
+	}
+
+	s.Unmerge(values, replaced)
+	if err := s.Write(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	got = decode(t, string(data))
+	want = decode(t, `{"theme": "dark", "env": {"A": "1"}}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after Unmerge() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Scope identifies which Claude Code settings file a profile is written to
+type Scope string
+
+const (
+	// ScopeUser is the global settings file (~/.claude/settings.json)
+	ScopeUser Scope = "user"
+	// ScopeProject is the shared project settings file (.claude/settings.json)
+	ScopeProject Scope = "project"
+	// ScopeLocal is the personal project settings file (.claude/settings.local.json)
+	ScopeLocal Scope = "local"
+)
+
+// Scopes lists all scopes ordered from highest to lowest precedence,
+// matching the order in which Claude Code applies them
+var Scopes = []Scope{ScopeLocal, ScopeProject, ScopeUser}
+
+// ParseScope converts a string into a Scope, defaulting to ScopeUser when empty
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case "":
+		return ScopeUser, nil
+	case ScopeUser, ScopeProject, ScopeLocal:
+		return Scope(s), nil
+	}
+	return "", fmt.Errorf("invalid scope '%s' (expected user, project or local)", s)
+}
+
+// ProjectPath returns the settings file for a project-level scope inside the given root
+func ProjectPath(scope Scope, root string) string {
+	if scope == ScopeLocal {
+		return filepath.Join(root, ".claude", "settings.local.json")
+	}
+	return filepath.Join(root, ".claude", "settings.json")
+}
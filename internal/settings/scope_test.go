@@ -0,0 +1,43 @@
+package settings
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Scope
+		wantErr bool
+	}{
+		{"", ScopeUser, false},
+		{"user", ScopeUser, false},
+		{"project", ScopeProject, false},
+		{"local", ScopeLocal, false},
+		{"global", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseScope(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScope(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseScope(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectPath(t *testing.T) {
+	root := filepath.Join("work", "repo")
+
+	if got, want := ProjectPath(ScopeProject, root), filepath.Join(root, ".claude", "settings.json"); got != want {
+		t.Errorf("ProjectPath(project) = %v, want %v", got, want)
+	}
+	if got, want := ProjectPath(ScopeLocal, root), filepath.Join(root, ".claude", "settings.local.json"); got != want {
+		t.Errorf("ProjectPath(local) = %v, want %v", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
 	"github.com/huangdijia/ccswitch/internal/pathutil"
 )
 
@@ -116,6 +117,6 @@ func (s *ClaudeSettings) Write() error {
 		return err
 	}
 
-	// Write to file
-	return os.WriteFile(s.Path, data, 0644)
+	// Replace the file atomically so a crash never leaves it truncated
+	return atomicfile.WriteFile(s.Path, data, 0644)
 }
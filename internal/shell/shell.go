@@ -0,0 +1,202 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// namePattern matches the variable names that are safe to emit unquoted
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Shell identifies a supported shell dialect
+type Shell string
+
+const (
+	Bash       Shell = "bash"
+	Zsh        Shell = "zsh"
+	Fish       Shell = "fish"
+	PowerShell Shell = "powershell"
+)
+
+// Parse converts a shell name into a Shell, accepting common aliases
+func Parse(name string) (Shell, error) {
+	switch strings.ToLower(name) {
+	case "bash", "sh":
+		return Bash, nil
+	case "zsh":
+		return Zsh, nil
+	case "fish":
+		return Fish, nil
+	case "powershell", "pwsh", "ps":
+		return PowerShell, nil
+	}
+	return "", fmt.Errorf("unsupported shell '%s' (expected bash, zsh, fish or powershell)", name)
+}
+
+// Detect guesses the current shell from the environment, defaulting to bash
+func Detect() Shell {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		if detected, err := Parse(filepath.Base(sh)); err == nil {
+			return detected
+		}
+	}
+	if os.Getenv("PSModulePath") != "" {
+		return PowerShell
+	}
+	return Bash
+}
+
+// Quote quotes value so the shell reads it back literally
+func Quote(sh Shell, value string) string {
+	switch sh {
+	case Fish:
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+	case PowerShell:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ValidateName returns an error unless name is a valid variable name. Names
+// are emitted unquoted, so anything else could inject shell code
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return nil
+}
+
+// Export returns statements that set every variable in env, sorted by name.
+// It fails without output if a name is invalid
+func Export(sh Shell, env map[string]string) (string, error) {
+	keys := sortedKeys(env)
+	if err := validateNames(keys); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := Quote(sh, env[key])
+		switch sh {
+		case Fish:
+			fmt.Fprintf(&b, "set -gx %s %s;\n", key, value)
+		case PowerShell:
+			fmt.Fprintf(&b, "$env:%s = %s\n", key, value)
+		default:
+			fmt.Fprintf(&b, "export %s=%s\n", key, value)
+		}
+	}
+	return b.String(), nil
+}
+
+// Unset returns statements that remove the given variables, sorted by name.
+// It fails without output if a name is invalid
+func Unset(sh Shell, keys []string) (string, error) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	if err := validateNames(sorted); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, key := range sorted {
+		switch sh {
+		case Fish:
+			fmt.Fprintf(&b, "set -e %s;\n", key)
+		case PowerShell:
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", key)
+		default:
+			fmt.Fprintf(&b, "unset %s\n", key)
+		}
+	}
+	return b.String(), nil
+}
+
+// Init returns the shell code that defines the ccs function. Running
+// "ccs <profile>" activates a profile in the current shell and
+// "ccs --unset" deactivates it.
+func Init(sh Shell, executable string) string {
+	exe := Quote(sh, executable)
+	switch sh {
+	case Fish:
+		return fmt.Sprintf(`function ccs --description 'Activate a ccswitch profile in this shell'
+    %s env --shell fish $argv | source
+end
+`, exe)
+	case PowerShell:
+		return fmt.Sprintf(`function ccs {
+    & %s env --shell powershell @args | Out-String | Invoke-Expression
+}
+`, exe)
+	}
+	return fmt.Sprintf(`ccs() {
+    eval "$(%s env --shell %s "$@")"
+}
+`, exe, sh)
+}
+
+// Hook returns shell code that runs "ccswitch auto" whenever the current
+// directory changes, so that pinned profiles are activated automatically
+func Hook(sh Shell, executable string) string {
+	exe := Quote(sh, executable)
+	switch sh {
+	case Zsh:
+		return fmt.Sprintf(`_ccswitch_hook() {
+    eval "$(%s auto --shell zsh)"
+}
+autoload -U add-zsh-hook
+add-zsh-hook chpwd _ccswitch_hook
+_ccswitch_hook
+`, exe)
+	case Fish:
+		return fmt.Sprintf(`function __ccswitch_hook --on-variable PWD
+    %s auto --shell fish | source
+end
+__ccswitch_hook
+`, exe)
+	case PowerShell:
+		return fmt.Sprintf(`$global:__ccswitchPrompt = $function:prompt
+function global:prompt {
+    if ($PWD.Path -ne $global:__ccswitchLastPwd) {
+        $global:__ccswitchLastPwd = $PWD.Path
+        & %s auto --shell powershell | Out-String | Invoke-Expression
+    }
+    & $global:__ccswitchPrompt
+}
+`, exe)
+	}
+	return fmt.Sprintf(`_ccswitch_hook() {
+    if [ "$PWD" != "$_CCSWITCH_LAST_PWD" ]; then
+        _CCSWITCH_LAST_PWD="$PWD"
+        eval "$(%s auto --shell bash)"
+    fi
+}
+case ";${PROMPT_COMMAND};" in
+    *";_ccswitch_hook;"*) ;;
+    *) PROMPT_COMMAND="_ccswitch_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`, exe)
+}
+
+func validateNames(names []string) error {
+	for _, name := range names {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
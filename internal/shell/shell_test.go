@@ -0,0 +1,192 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Shell
+		wantErr bool
+	}{
+		{"bash", Bash, false},
+		{"zsh", Zsh, false},
+		{"fish", Fish, false},
+		{"pwsh", PowerShell, false},
+		{"PowerShell", PowerShell, false},
+		{"tcsh", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("SHELL", "/usr/bin/fish")
+	if got := Detect(); got != Fish {
+		t.Errorf("Detect() = %v, want %v", got, Fish)
+	}
+
+	t.Setenv("SHELL", "")
+	t.Setenv("PSModulePath", "C:\\modules")
+	if got := Detect(); got != PowerShell {
+		t.Errorf("Detect() = %v, want %v", got, PowerShell)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		shell Shell
+		value string
+		want  string
+	}{
+		{Bash, "plain", "'plain'"},
+		{Bash, "it's $HOME", `'it'\''s $HOME'`},
+		{Fish, `it's a\b`, `'it\'s a\\b'`},
+		{PowerShell, "it's $env:HOME", "'it''s $env:HOME'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			if got := Quote(tt.shell, tt.value); got != tt.want {
+				t.Errorf("Quote(%v, %q) = %v, want %v", tt.shell, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_MODEL":    "opus",
+		"ANTHROPIC_BASE_URL": "https://api.test.com",
+	}
+
+	tests := []struct {
+		shell Shell
+		want  string
+	}{
+		{Bash, "export ANTHROPIC_BASE_URL='https://api.test.com'\nexport ANTHROPIC_MODEL='opus'\n"},
+		{Fish, "set -gx ANTHROPIC_BASE_URL 'https://api.test.com';\nset -gx ANTHROPIC_MODEL 'opus';\n"},
+		{PowerShell, "$env:ANTHROPIC_BASE_URL = 'https://api.test.com'\n$env:ANTHROPIC_MODEL = 'opus'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			got, err := Export(tt.shell, env)
+			if err != nil {
+				t.Fatalf("Export(%v) error = %v", tt.shell, err)
+			}
+			if got != tt.want {
+				t.Errorf("Export(%v) = %q, want %q", tt.shell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnset(t *testing.T) {
+	keys := []string{"B", "A"}
+
+	if got, want := mustUnset(t, Bash, keys), "unset A\nunset B\n"; got != want {
+		t.Errorf("Unset(bash) = %q, want %q", got, want)
+	}
+	if got, want := mustUnset(t, Fish, keys), "set -e A;\nset -e B;\n"; got != want {
+		t.Errorf("Unset(fish) = %q, want %q", got, want)
+	}
+	if got := mustUnset(t, PowerShell, keys); !strings.Contains(got, "Remove-Item Env:A") {
+		t.Errorf("Unset(powershell) = %q, want Remove-Item statements", got)
+	}
+}
+
+func mustUnset(t *testing.T, sh Shell, keys []string) string {
+	t.Helper()
+	got, err := Unset(sh, keys)
+	if err != nil {
+		t.Fatalf("Unset(%v) error = %v", sh, err)
+	}
+	return got
+}
+
+func TestInvalidNames(t *testing.T) {
+	for _, name := range []string{"X;echo PWNED;Y", "1A", "A B", "A=B", "$(id)", ""} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) = nil, want error", name)
+		}
+		for _, sh := range []Shell{Bash, Fish, PowerShell} {
+			if got, err := Export(sh, map[string]string{"OK": "1", name: "1"}); err == nil || got != "" {
+				t.Errorf("Export(%v, %q) = %q, %v, want an error and no output", sh, name, got, err)
+			}
+			if got, err := Unset(sh, []string{"OK", name}); err == nil || got != "" {
+				t.Errorf("Unset(%v, %q) = %q, %v, want an error and no output", sh, name, got, err)
+			}
+		}
+	}
+	for _, name := range []string{"A", "_a1", "ANTHROPIC_BASE_URL"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+}
+
+func TestExportRoundTripBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	value := `it's "quoted" $HOME \n; rm -rf /`
+	exports, err := Export(Bash, map[string]string{"CCSWITCH_TEST": value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := exports + `printf %s "$CCSWITCH_TEST"`
+
+	out, err := exec.Command(bash, "-c", script).Output()
+	if err != nil {
+		t.Fatalf("bash error = %v", err)
+	}
+	if string(out) != value {
+		t.Errorf("bash read back %q, want %q", out, value)
+	}
+}
+
+func TestInit(t *testing.T) {
+	for _, sh := range []Shell{Bash, Zsh, Fish, PowerShell} {
+		script := Init(sh, "/usr/local/bin/ccswitch")
+		if !strings.Contains(script, "ccs") || !strings.Contains(script, "/usr/local/bin/ccswitch") {
+			t.Errorf("Init(%v) = %q, want a ccs function calling the executable", sh, script)
+		}
+	}
+}
+
+func TestHook(t *testing.T) {
+	tests := []struct {
+		shell Shell
+		want  string
+	}{
+		{Bash, "PROMPT_COMMAND"},
+		{Zsh, "add-zsh-hook chpwd"},
+		{Fish, "--on-variable PWD"},
+		{PowerShell, "function global:prompt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			script := Hook(tt.shell, "ccswitch")
+			if !strings.Contains(script, tt.want) || !strings.Contains(script, "auto --shell") {
+				t.Errorf("Hook(%v) = %q, want it to contain %q", tt.shell, script, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package termui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Filter returns the indices of the items that fuzzily match query, best
+// match first. Items with equal scores keep their order; an empty query
+// matches every item
+func Filter(items []string, query string) []int {
+	type scored struct {
+		index int
+		score int
+	}
+
+	var matches []scored
+	for i, item := range items {
+		if score, ok := fuzzyScore(item, query); ok {
+			matches = append(matches, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return b.score - a.score })
+
+	indices := make([]int, len(matches))
+	for i, m := range matches {
+		indices[i] = m.index
+	}
+	return indices
+}
+
+// fuzzyScore reports whether the runes of query appear in s in order,
+// ignoring case, and scores the match. Prefixes, substrings, consecutive
+// runes and runes at the start of a word score higher
+func fuzzyScore(s, query string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	lower := strings.ToLower(s)
+	needle := strings.ToLower(query)
+
+	score := 0
+	switch {
+	case strings.HasPrefix(lower, needle):
+		score += 50
+	case strings.Contains(lower, needle):
+		score += 25
+	}
+
+	runes := []rune(lower)
+	want := []rune(needle)
+	matched, last := 0, -2
+	for i, r := range runes {
+		if matched == len(want) {
+			break
+		}
+		if r != want[matched] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 5
+		}
+		if i == 0 || isWordSeparator(runes[i-1]) {
+			score += 8
+		}
+		if matched == 0 {
+			score -= min(i, 10)
+		}
+		matched++
+		last = i
+	}
+	return score, matched == len(want)
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("-_./:", r)
+}
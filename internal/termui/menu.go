@@ -0,0 +1,222 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/huangdijia/ccswitch/internal/output"
+)
+
+// ErrNoInput is returned by SelectMenu when the input ends before an item
+// was chosen
+var ErrNoInput = errors.New("no selection read from input")
+
+// Select lets the user pick one of cfg.Items with SelectString on a
+// terminal, and with SelectMenu when tui is false, TERM is dumb or the
+// input or output is not a terminal
+func Select(cfg SelectConfig, tui bool) (string, error) {
+	if tui && os.Getenv("TERM") != "dumb" {
+		selected, err := SelectString(cfg)
+		if !errors.Is(err, ErrNotTerminal) {
+			return selected, err
+		}
+	}
+	return SelectMenu(cfg)
+}
+
+// SelectMany is Select for several items, using SelectStrings and
+// SelectMenuMany
+func SelectMany(cfg SelectConfig, tui bool) ([]string, error) {
+	if tui && os.Getenv("TERM") != "dumb" {
+		selected, err := SelectStrings(cfg)
+		if !errors.Is(err, ErrNotTerminal) {
+			return selected, err
+		}
+	}
+	return SelectMenuMany(cfg)
+}
+
+// SelectMenu is the line-based fallback of SelectString. It prints the items
+// as a numbered list and reads a number or an item name from cfg.In; an
+// empty line picks the default item. It asks again after invalid input and
+// returns ErrCanceled for "q"
+func SelectMenu(cfg SelectConfig) (string, error) {
+	if cfg.DefaultIndex < 0 || cfg.DefaultIndex >= len(cfg.Items) {
+		cfg.DefaultIndex = 0
+	}
+
+	var selected string
+	err := runMenu(cfg, true, fmt.Sprintf("Enter number or name [%d], q to cancel: ", cfg.DefaultIndex+1), func(input string) bool {
+		var ok bool
+		selected, ok = menuChoice(cfg.Items, cfg.DefaultIndex, input)
+		return ok
+	})
+	return selected, err
+}
+
+// SelectMenuMany is the line-based fallback of SelectStrings. It reads
+// numbers, ranges such as 2-4 and item names separated by spaces or commas,
+// or "a" for all items
+func SelectMenuMany(cfg SelectConfig) ([]string, error) {
+	var selected []string
+	err := runMenu(cfg, false, "Enter numbers, ranges or names, a for all, q to cancel: ", func(input string) bool {
+		var ok bool
+		selected, ok = menuChoices(cfg.Items, input)
+		return ok
+	})
+	return selected, err
+}
+
+// runMenu prints the numbered items, marking the default one if markDefault
+// is set, and reads lines until choose accepts one
+func runMenu(cfg SelectConfig, markDefault bool, prompt string, choose func(input string) bool) error {
+	if cfg.In == nil || cfg.Out == nil {
+		return fmt.Errorf("invalid io")
+	}
+	if len(cfg.Items) == 0 {
+		return fmt.Errorf("no items to select")
+	}
+	if cfg.Prompt == "" {
+		cfg.Prompt = "Select:"
+	}
+
+	nameWidth := 0
+	for _, item := range cfg.Items {
+		nameWidth = max(nameWidth, output.DisplayWidth(item))
+	}
+	numberWidth := len(strconv.Itoa(len(cfg.Items)))
+
+	fmt.Fprintln(cfg.Out, cfg.Prompt)
+	for i, item := range cfg.Items {
+		marker := " "
+		if markDefault && i == cfg.DefaultIndex {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %*d) %s", marker, numberWidth, i+1, item)
+		if cfg.Details != nil {
+			if details := cfg.Details(item); details != "" {
+				line = fmt.Sprintf("%s %*d) %s  %s", marker, numberWidth, i+1, output.Pad(item, nameWidth), details)
+			}
+		}
+		fmt.Fprintln(cfg.Out, line)
+	}
+
+	for {
+		fmt.Fprint(cfg.Out, prompt)
+		input, err := readLine(cfg.In)
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(cfg.Out)
+			return ErrNoInput
+		}
+		if err != nil {
+			return err
+		}
+
+		input = strings.TrimSpace(input)
+		if (input == "q" || input == "Q") && !slices.Contains(cfg.Items, input) {
+			return ErrCanceled
+		}
+		if choose(input) {
+			return nil
+		}
+		fmt.Fprintf(cfg.Out, "Invalid selection %q\n", input)
+	}
+}
+
+// readLine reads one line from r without reading ahead, so that later
+// prompts can read the rest of the input. io.EOF is only returned when
+// nothing was read
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// menuChoice resolves the input of SelectMenu: a number, an item name, a
+// query matching a single item or an empty line for the default item
+func menuChoice(items []string, defaultIndex int, input string) (string, bool) {
+	if input == "" {
+		return items[defaultIndex], true
+	}
+	index, ok := menuItem(items, input)
+	if !ok {
+		return "", false
+	}
+	return items[index], true
+}
+
+// menuChoices resolves the input of SelectMenuMany into items in their
+// original order
+func menuChoices(items []string, input string) ([]string, bool) {
+	if (input == "a" || input == "all") && !slices.Contains(items, input) {
+		return slices.Clone(items), true
+	}
+
+	chosen := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if from, to, isRange := strings.Cut(field, "-"); isRange {
+			first, err1 := strconv.Atoi(from)
+			last, err2 := strconv.Atoi(to)
+			if err1 == nil && err2 == nil {
+				if first < 1 || last > len(items) || first > last {
+					return nil, false
+				}
+				for i := first - 1; i < last; i++ {
+					chosen[i] = true
+				}
+				continue
+			}
+		}
+
+		index, ok := menuItem(items, field)
+		if !ok {
+			return nil, false
+		}
+		chosen[index] = true
+	}
+	if len(chosen) == 0 {
+		return nil, false
+	}
+
+	var selected []string
+	for i, item := range items {
+		if chosen[i] {
+			selected = append(selected, item)
+		}
+	}
+	return selected, true
+}
+
+// menuItem finds the item named, numbered or uniquely matched by input
+func menuItem(items []string, input string) (int, bool) {
+	if i := slices.Index(items, input); i >= 0 {
+		return i, true
+	}
+	if n, err := strconv.Atoi(input); err == nil {
+		return n - 1, n >= 1 && n <= len(items)
+	}
+	if matches := Filter(items, input); len(matches) == 1 {
+		return matches[0], true
+	}
+	return 0, false
+}
@@ -0,0 +1,115 @@
+package termui
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSelectMenu(t *testing.T) {
+	items := []string{"deepseek", "glm", "kimi-k2", "kimi-kfc"}
+	details := map[string]string{"glm": "Zhipu GLM · https://open.bigmodel.cn/api/anthropic"}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "number", input: "3\n", want: "kimi-k2"},
+		{name: "name", input: "kimi-kfc\n", want: "kimi-kfc"},
+		{name: "default", input: "\n", want: "glm"},
+		{name: "unique match", input: "kfc\n", want: "kimi-kfc"},
+		{name: "last line without newline", input: "1", want: "deepseek"},
+		{name: "retry after invalid input", input: "9\nkimi\nfoo\n4\n", want: "kimi-kfc"},
+		{name: "cancel", input: "q\n", wantErr: ErrCanceled},
+		{name: "no input", input: "", wantErr: ErrNoInput},
+		{name: "only invalid input", input: "0\n", wantErr: ErrNoInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := SelectMenu(SelectConfig{
+				In:           strings.NewReader(tt.input),
+				Out:          &out,
+				Prompt:       "Select profile:",
+				Items:        items,
+				DefaultIndex: 1,
+				Details:      func(item string) string { return details[item] },
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SelectMenu() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SelectMenu() = %q, want %q", got, tt.want)
+			}
+
+			menu := out.String()
+			for _, want := range []string{
+				"Select profile:\n",
+				"  1) deepseek\n",
+				"* 2) glm       Zhipu GLM · https://open.bigmodel.cn/api/anthropic\n",
+				"Enter number or name [2], q to cancel: ",
+			} {
+				if !strings.Contains(menu, want) {
+					t.Errorf("menu does not contain %q:\n%s", want, menu)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectFallsBackToMenu(t *testing.T) {
+	var out bytes.Buffer
+	got, err := Select(SelectConfig{
+		In:    strings.NewReader("2\n"),
+		Out:   &out,
+		Items: []string{"a", "b"},
+	}, true)
+	if err != nil || got != "b" {
+		t.Errorf("Select() = %q, %v, want b from the menu", got, err)
+	}
+}
+
+func TestSelectMenuMany(t *testing.T) {
+	items := []string{"deepseek", "glm", "kimi-k2", "kimi-kfc"}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{name: "numbers", input: "3 1\n", want: []string{"deepseek", "kimi-k2"}},
+		{name: "range", input: "2-4\n", want: []string{"glm", "kimi-k2", "kimi-kfc"}},
+		{name: "names and matches", input: "glm, kfc\n", want: []string{"glm", "kimi-kfc"}},
+		{name: "all", input: "a\n", want: items},
+		{name: "retry after invalid input", input: "\n1 9\n4-2\nkimi\n2,3\n", want: []string{"glm", "kimi-k2"}},
+		{name: "cancel", input: "q\n", wantErr: ErrCanceled},
+		{name: "no input", input: "", wantErr: ErrNoInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := SelectMenuMany(SelectConfig{
+				In:     strings.NewReader(tt.input),
+				Out:    &out,
+				Prompt: "Select profiles:",
+				Items:  items,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SelectMenuMany() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SelectMenuMany() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "  1) deepseek\n") {
+				t.Errorf("menu does not list the items:\n%s", out.String())
+			}
+		})
+	}
+}
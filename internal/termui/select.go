@@ -3,8 +3,12 @@ package termui
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/huangdijia/ccswitch/internal/output"
 	"golang.org/x/term"
 )
 
@@ -15,41 +19,81 @@ var (
 	ErrNotTerminal = errors.New("not a terminal")
 )
 
+// Hints shown below the items when SelectConfig.Hint is empty
+const (
+	defaultHint      = "Type to filter, ↑/↓ PgUp/PgDn to move, Enter to select, Esc to cancel"
+	defaultMultiHint = "Space to toggle, a for all, / to filter, Enter to confirm, Esc to cancel"
+)
+
 type SelectConfig struct {
-	In           *os.File
-	Out          *os.File
+	// In and Out must be a terminal for SelectString; SelectMenu accepts
+	// any reader and writer
+	In           io.Reader
+	Out          io.Writer
 	Prompt       string
 	Hint         string
 	Items        []string
 	DefaultIndex int
+	// Details describes the highlighted item on a line below the items;
+	// it is optional
+	Details func(item string) string
 }
 
+// SelectString lets the user pick one of cfg.Items. Typing filters the items
+// fuzzily and the list scrolls when it is taller than the terminal
 func SelectString(cfg SelectConfig) (string, error) {
+	if cfg.Hint == "" {
+		cfg.Hint = defaultHint
+	}
+	s := newSelector(cfg.Items, cfg.DefaultIndex)
+	if err := runSelector(cfg, s); err != nil {
+		return "", err
+	}
+	return s.current(), nil
+}
+
+// SelectStrings lets the user pick any number of cfg.Items. Space toggles
+// the highlighted item, "a" toggles all shown items and "/" starts a filter.
+// Enter without toggled items picks the highlighted item
+func SelectStrings(cfg SelectConfig) ([]string, error) {
+	if cfg.Hint == "" {
+		cfg.Hint = defaultMultiHint
+	}
+	s := newSelector(cfg.Items, cfg.DefaultIndex)
+	s.multi = true
+	if err := runSelector(cfg, s); err != nil {
+		return nil, err
+	}
+	return s.chosenItems(), nil
+}
+
+// runSelector draws s on the terminal and passes it key presses until the
+// selection is made or canceled
+func runSelector(cfg SelectConfig, s *selector) error {
 	if cfg.In == nil || cfg.Out == nil {
-		return "", fmt.Errorf("invalid terminal io")
+		return fmt.Errorf("invalid terminal io")
 	}
 	if len(cfg.Items) == 0 {
-		return "", fmt.Errorf("no items to select")
-	}
-	if cfg.DefaultIndex < 0 || cfg.DefaultIndex >= len(cfg.Items) {
-		cfg.DefaultIndex = 0
+		return fmt.Errorf("no items to select")
 	}
 	if cfg.Prompt == "" {
 		cfg.Prompt = "Select:"
 	}
-	if cfg.Hint == "" {
-		cfg.Hint = "↑/↓ to move, Enter to select, q to cancel"
-	}
 
-	inFD := int(cfg.In.Fd())
-	outFD := int(cfg.Out.Fd())
+	inFile, inOK := cfg.In.(*os.File)
+	outFile, outOK := cfg.Out.(*os.File)
+	if !inOK || !outOK {
+		return ErrNotTerminal
+	}
+	inFD := int(inFile.Fd())
+	outFD := int(outFile.Fd())
 	if !term.IsTerminal(inFD) || !term.IsTerminal(outFD) {
-		return "", ErrNotTerminal
+		return ErrNotTerminal
 	}
 
 	previousState, err := term.MakeRaw(inFD)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer func() { _ = term.Restore(inFD, previousState) }()
 
@@ -57,121 +101,445 @@ func SelectString(cfg SelectConfig) (string, error) {
 	fmt.Fprint(cfg.Out, "\x1b[?25l")
 	defer fmt.Fprint(cfg.Out, "\x1b[?25h")
 
-	selected := cfg.DefaultIndex
 	renderedLines := 0
 
 	render := func() {
+		width, height, err := term.GetSize(outFD)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		// Leave room for the prompt, details and hint lines
+		s.setHeight(height - 4)
+
 		if renderedLines > 0 {
 			fmt.Fprintf(cfg.Out, "\x1b[%dA", renderedLines)
 		}
-
-		// Prompt line.
-		fmt.Fprint(cfg.Out, "\x1b[2K\r")
-		fmt.Fprint(cfg.Out, cfg.Prompt)
-		fmt.Fprint(cfg.Out, "\r\n")
-
-		// Items.
-		for i, item := range cfg.Items {
-			line := "  " + item
-			if i == selected {
-				line = "\x1b[7m> " + item + "\x1b[0m"
-			}
+		lines := s.lines(cfg.Prompt, cfg.Hint, cfg.Details)
+		for _, line := range lines {
 			fmt.Fprint(cfg.Out, "\x1b[2K\r")
-			fmt.Fprint(cfg.Out, line)
+			fmt.Fprint(cfg.Out, styleLine(line, width))
 			fmt.Fprint(cfg.Out, "\r\n")
 		}
-
-		// Hint line.
-		fmt.Fprint(cfg.Out, "\x1b[2K\r")
-		fmt.Fprint(cfg.Out, cfg.Hint)
-		fmt.Fprint(cfg.Out, "\r\n")
-
-		renderedLines = 1 + len(cfg.Items) + 1
+		// Clear lines left over from a longer previous frame
+		fmt.Fprint(cfg.Out, "\x1b[J")
+		renderedLines = len(lines)
 	}
 
 	render()
 
-	// Simple escape-sequence parser (supports arrow keys).
-	escPending := false
-	csiPending := false
-	ss3Pending := false
-
-	buf := make([]byte, 1)
+	buf := make([]byte, 64)
+	var pending []byte
 	for {
 		n, err := cfg.In.Read(buf)
 		if err != nil {
-			return "", err
+			return err
 		}
 		if n == 0 {
 			continue
 		}
-		b := buf[0]
-
-		if csiPending {
-			csiPending = false
-			switch b {
-			case 'A':
-				if selected > 0 {
-					selected--
-					render()
-				}
-			case 'B':
-				if selected < len(cfg.Items)-1 {
-					selected++
-					render()
-				}
+
+		var keys []key
+		keys, pending = parseKeys(append(pending, buf[:n]...))
+		for _, k := range keys {
+			switch s.handle(k) {
+			case selectDone:
+				fmt.Fprint(cfg.Out, "\r\n")
+				return nil
+			case selectCanceled:
+				return ErrCanceled
 			}
-			continue
 		}
-		if ss3Pending {
-			ss3Pending = false
-			switch b {
-			case 'A':
-				if selected > 0 {
-					selected--
-					render()
-				}
-			case 'B':
-				if selected < len(cfg.Items)-1 {
-					selected++
-					render()
-				}
+		render()
+	}
+}
+
+// line is a rendered line of the selector; kind decides its style
+type line struct {
+	text string
+	kind lineKind
+}
+
+type lineKind int
+
+const (
+	linePlain lineKind = iota
+	lineSelected
+	lineDim
+)
+
+// styleLine truncates l to the terminal width, so that no line wraps, and
+// applies its style
+func styleLine(l line, width int) string {
+	text := output.Truncate(l.text, width-1)
+	switch l.kind {
+	case lineSelected:
+		return "\x1b[7m" + text + "\x1b[0m"
+	case lineDim:
+		return "\x1b[2m" + text + "\x1b[0m"
+	}
+	return text
+}
+
+// selectResult is the outcome of a key press
+type selectResult int
+
+const (
+	selectContinue selectResult = iota
+	selectDone
+	selectCanceled
+)
+
+// selector holds the state of SelectString apart from the terminal
+type selector struct {
+	items []string
+	query []rune
+	// matches are the indices of the items matching the query, in the order
+	// they are shown
+	matches []int
+	// selected is the highlighted position in matches
+	selected int
+	// top is the first position in matches that is shown
+	top int
+	// height is the number of items shown at once
+	height int
+
+	// multi lets several items be chosen; letters are then commands unless
+	// filtering is set
+	multi     bool
+	filtering bool
+	// chosen holds the indices of the chosen items
+	chosen map[int]bool
+}
+
+func newSelector(items []string, defaultIndex int) *selector {
+	s := &selector{items: items, height: len(items), chosen: make(map[int]bool)}
+	s.matches = Filter(items, "")
+	if defaultIndex >= 0 && defaultIndex < len(items) {
+		s.selected = defaultIndex
+	}
+	s.scroll()
+	return s
+}
+
+// current returns the highlighted item, or an empty string if no item
+// matches the query
+func (s *selector) current() string {
+	if len(s.matches) == 0 {
+		return ""
+	}
+	return s.items[s.matches[s.selected]]
+}
+
+// chosenItems returns the chosen items in their original order, or the
+// highlighted item when none is chosen
+func (s *selector) chosenItems() []string {
+	var items []string
+	for i, item := range s.items {
+		if s.chosen[i] {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 && len(s.matches) > 0 {
+		items = append(items, s.current())
+	}
+	return items
+}
+
+func (s *selector) setHeight(height int) {
+	s.height = max(height, 1)
+	s.scroll()
+}
+
+func (s *selector) handle(k key) selectResult {
+	if s.multi {
+		if result, handled := s.handleMulti(k); handled {
+			return result
+		}
+	}
+
+	switch k.kind {
+	case keyEnter:
+		if len(s.matches) == 0 {
+			return selectContinue
+		}
+		return selectDone
+	case keyCancel:
+		return selectCanceled
+	case keyUp:
+		s.move(-1)
+	case keyDown:
+		s.move(1)
+	case keyPageUp:
+		s.move(-s.height)
+	case keyPageDown:
+		s.move(s.height)
+	case keyHome:
+		s.move(-len(s.matches))
+	case keyEnd:
+		s.move(len(s.matches))
+	case keyRune:
+		s.setQuery(append(s.query, k.r))
+	case keyBackspace:
+		if len(s.query) > 0 {
+			s.setQuery(s.query[:len(s.query)-1])
+		}
+	case keyClear:
+		s.setQuery(nil)
+	}
+	return selectContinue
+}
+
+// handleMulti handles the keys that differ in multi-select mode
+func (s *selector) handleMulti(k key) (selectResult, bool) {
+	if s.filtering {
+		switch k.kind {
+		case keyEnter:
+			s.filtering = false
+			return selectContinue, true
+		case keyCancel:
+			s.filtering = false
+			s.setQuery(nil)
+			return selectContinue, true
+		}
+		return selectContinue, false
+	}
+
+	if k.kind != keyRune {
+		return selectContinue, false
+	}
+	switch k.r {
+	case ' ':
+		if len(s.matches) > 0 {
+			s.setChosen(s.matches[s.selected], !s.chosen[s.matches[s.selected]])
+		}
+	case 'a', 'A':
+		s.toggleAll()
+	case '/':
+		s.filtering = true
+	case 'j':
+		s.move(1)
+	case 'k':
+		s.move(-1)
+	case 'q':
+		return selectCanceled, true
+	}
+	return selectContinue, true
+}
+
+// toggleAll chooses every shown item, or unchooses them if they are all
+// chosen already
+func (s *selector) toggleAll() {
+	all := true
+	for _, index := range s.matches {
+		all = all && s.chosen[index]
+	}
+	for _, index := range s.matches {
+		s.setChosen(index, !all)
+	}
+}
+
+func (s *selector) setChosen(index int, chosen bool) {
+	if chosen {
+		s.chosen[index] = true
+	} else {
+		delete(s.chosen, index)
+	}
+}
+
+func (s *selector) move(delta int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.selected = min(max(s.selected+delta, 0), len(s.matches)-1)
+	s.scroll()
+}
+
+// setQuery filters the items again, keeping the highlighted item when it
+// still matches and highlighting the best match otherwise
+func (s *selector) setQuery(query []rune) {
+	current := s.current()
+	s.query = query
+	s.matches = Filter(s.items, string(query))
+	s.selected = 0
+	if string(query) == "" {
+		for i, index := range s.matches {
+			if s.items[index] == current {
+				s.selected = i
 			}
-			continue
 		}
-		if escPending {
-			escPending = false
-			switch b {
-			case '[':
-				csiPending = true
-			case 'O':
-				ss3Pending = true
-			case 27: // ESC ESC
-				return "", ErrCanceled
+	}
+	s.top = 0
+	s.scroll()
+}
+
+// scroll moves the viewport so that the highlighted item is visible
+func (s *selector) scroll() {
+	if s.selected < s.top {
+		s.top = s.selected
+	}
+	if s.selected >= s.top+s.height {
+		s.top = s.selected - s.height + 1
+	}
+	s.top = max(min(s.top, len(s.matches)-s.height), 0)
+}
+
+// lines returns the lines of the selector: the prompt with the query, the
+// visible items, the details of the highlighted item and the hint
+func (s *selector) lines(prompt, hint string, details func(string) string) []line {
+	query := string(s.query)
+	if s.filtering {
+		query = "/" + query
+	}
+	lines := []line{{text: prompt + " " + query}}
+
+	if len(s.matches) == 0 {
+		lines = append(lines, line{text: "  (no matches)", kind: lineDim})
+	}
+	end := min(s.top+s.height, len(s.matches))
+	for i := s.top; i < end; i++ {
+		item := s.items[s.matches[i]]
+		if s.multi {
+			if s.chosen[s.matches[i]] {
+				item = "[x] " + item
+			} else {
+				item = "[ ] " + item
 			}
-			continue
 		}
+		if i == s.selected {
+			lines = append(lines, line{text: "> " + item, kind: lineSelected})
+		} else {
+			lines = append(lines, line{text: "  " + item})
+		}
+	}
 
-		switch b {
-		case '\r', '\n':
-			fmt.Fprint(cfg.Out, "\r\n")
-			return cfg.Items[selected], nil
-		case 3: // Ctrl+C (in raw mode)
-			return "", ErrCanceled
-		case 'q', 'Q':
-			return "", ErrCanceled
-		case 'k', 'K':
-			if selected > 0 {
-				selected--
-				render()
+	if details != nil && len(s.matches) > 0 {
+		lines = append(lines, line{text: "  " + details(s.current()), kind: lineDim})
+	}
+
+	if len(s.query) > 0 || len(s.matches) > s.height {
+		position := 0
+		if len(s.matches) > 0 {
+			position = s.selected + 1
+		}
+		hint = fmt.Sprintf("%s  [%d/%d of %d]", hint, position, len(s.matches), len(s.items))
+	}
+	if s.multi {
+		hint = fmt.Sprintf("%s  (%d chosen)", hint, len(s.chosen))
+	}
+	lines = append(lines, line{text: hint, kind: lineDim})
+	return lines
+}
+
+// key is a key press read from the terminal
+type key struct {
+	kind keyKind
+	// r is the typed rune of a keyRune
+	r rune
+}
+
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyBackspace
+	keyClear
+	keyCancel
+	keyUnknown
+)
+
+// parseKeys decodes the key presses in data. An incomplete escape sequence
+// or UTF-8 rune at the end is returned as rest, to be completed by the next
+// read. A lone ESC cancels, as terminals send escape sequences in one write
+func parseKeys(data []byte) (keys []key, rest []byte) {
+	for len(data) > 0 {
+		b := data[0]
+		switch {
+		case b == 27:
+			k, n := parseEscape(data)
+			if n == 0 {
+				return keys, data
+			}
+			keys = append(keys, k)
+			data = data[n:]
+			continue
+		case b == '\r' || b == '\n':
+			keys = append(keys, key{kind: keyEnter})
+		case b == 3: // Ctrl+C (in raw mode)
+			keys = append(keys, key{kind: keyCancel})
+		case b == 127 || b == 8:
+			keys = append(keys, key{kind: keyBackspace})
+		case b == 21: // Ctrl+U
+			keys = append(keys, key{kind: keyClear})
+		case b == 16: // Ctrl+P
+			keys = append(keys, key{kind: keyUp})
+		case b == 14: // Ctrl+N
+			keys = append(keys, key{kind: keyDown})
+		case b < ' ':
+			// Other control keys are ignored
+		default:
+			if !utf8.FullRune(data) {
+				return keys, data
 			}
-		case 'j', 'J':
-			if selected < len(cfg.Items)-1 {
-				selected++
-				render()
+			r, n := utf8.DecodeRune(data)
+			if r != utf8.RuneError {
+				keys = append(keys, key{kind: keyRune, r: r})
 			}
-		case 27: // ESC prefix (for arrows or cancellation via ESC ESC)
-			escPending = true
+			data = data[n:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys, nil
+}
+
+// parseEscape decodes the escape sequence at the start of data and returns
+// its length, or 0 if the sequence is incomplete
+func parseEscape(data []byte) (key, int) {
+	if len(data) == 1 || data[1] == 27 {
+		return key{kind: keyCancel}, min(len(data), 2)
+	}
+	if data[1] != '[' && data[1] != 'O' {
+		// Alt+key
+		return key{kind: keyUnknown}, 2
+	}
+
+	// CSI or SS3: parameters followed by a final byte
+	end := 2
+	for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+		end++
+	}
+	if end == len(data) {
+		return key{}, 0
+	}
+	params := string(data[2:end])
+	kind := keyUnknown
+	switch data[end] {
+	case 'A':
+		kind = keyUp
+	case 'B':
+		kind = keyDown
+	case 'H':
+		kind = keyHome
+	case 'F':
+		kind = keyEnd
+	case '~':
+		switch strings.SplitN(params, ";", 2)[0] {
+		case "1", "7":
+			kind = keyHome
+		case "4", "8":
+			kind = keyEnd
+		case "5":
+			kind = keyPageUp
+		case "6":
+			kind = keyPageDown
 		}
 	}
+	return key{kind: kind}, end + 1
 }
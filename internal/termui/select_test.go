@@ -0,0 +1,183 @@
+package termui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	items := []string{"anyrouter", "deepseek", "glm", "glm-air", "kimi-k2", "kimi-kfc", "minimaxi-m2", "modelscope"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", items},
+		{"glm", []string{"glm", "glm-air"}},
+		{"kk", []string{"kimi-k2", "kimi-kfc"}},
+		{"KFC", []string{"kimi-kfc"}},
+		{"ms", []string{"modelscope"}},
+		{"m2", []string{"minimaxi-m2", "kimi-k2"}},
+		{"xyz", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, i := range Filter(items, tt.query) {
+			got = append(got, items[i])
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Filter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, rest := parseKeys([]byte("g\x1b[A\x1b[B\x1b[5~\x1b[6~\x1bOH\x1b[F\x7f\r智"))
+	want := []keyKind{keyRune, keyUp, keyDown, keyPageUp, keyPageDown, keyHome, keyEnd, keyBackspace, keyEnter, keyRune}
+	if len(rest) != 0 || len(keys) != len(want) {
+		t.Fatalf("parseKeys() = %v, rest %q", keys, rest)
+	}
+	for i, k := range keys {
+		if k.kind != want[i] {
+			t.Errorf("key %d = %v, want %v", i, k.kind, want[i])
+		}
+	}
+	if keys[9].r != '智' {
+		t.Errorf("rune = %q, want 智", keys[9].r)
+	}
+
+	// Incomplete sequences are kept for the next read
+	keys, rest = parseKeys([]byte("a\x1b[5"))
+	if len(keys) != 1 || string(rest) != "\x1b[5" {
+		t.Errorf("parseKeys(incomplete escape) = %v, rest %q", keys, rest)
+	}
+	keys, rest = parseKeys([]byte("智")[:2])
+	if len(keys) != 0 || len(rest) != 2 {
+		t.Errorf("parseKeys(incomplete rune) = %v, rest %q", keys, rest)
+	}
+
+	// A lone ESC cancels
+	if keys, _ := parseKeys([]byte{27}); len(keys) != 1 || keys[0].kind != keyCancel {
+		t.Errorf("parseKeys(ESC) = %v, want cancel", keys)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	var items []string
+	for i := range 30 {
+		items = append(items, fmt.Sprintf("profile-%02d", i))
+	}
+	s := newSelector(items, 25)
+	s.setHeight(10)
+
+	if s.current() != "profile-25" || s.top != 16 {
+		t.Errorf("default = %s at top %d, want profile-25 visible", s.current(), s.top)
+	}
+
+	s.handle(key{kind: keyHome})
+	if s.current() != "profile-00" || s.top != 0 {
+		t.Errorf("Home = %s at top %d", s.current(), s.top)
+	}
+	s.handle(key{kind: keyPageDown})
+	if s.current() != "profile-10" || s.top != 1 {
+		t.Errorf("PgDn = %s at top %d", s.current(), s.top)
+	}
+	s.handle(key{kind: keyEnd})
+	if s.current() != "profile-29" || s.top != 20 {
+		t.Errorf("End = %s at top %d", s.current(), s.top)
+	}
+	if lines := s.lines("Select:", "hint", nil); len(lines) != 12 {
+		t.Errorf("lines = %d, want prompt, 10 items and hint", len(lines))
+	}
+
+	for _, r := range "17" {
+		s.handle(key{kind: keyRune, r: r})
+	}
+	if s.current() != "profile-17" || len(s.matches) != 1 {
+		t.Errorf("filter = %s with %d matches", s.current(), len(s.matches))
+	}
+	s.handle(key{kind: keyRune, r: 'x'})
+	if s.current() != "" || s.handle(key{kind: keyEnter}) != selectContinue {
+		t.Error("Enter without matches should not select")
+	}
+	s.handle(key{kind: keyBackspace})
+	if s.handle(key{kind: keyEnter}) != selectDone || s.current() != "profile-17" {
+		t.Errorf("Enter = %s, want profile-17", s.current())
+	}
+
+	s.handle(key{kind: keyClear})
+	if s.current() != "profile-17" || len(s.matches) != len(items) {
+		t.Errorf("clearing the query lost the highlighted item: %s", s.current())
+	}
+	if s.handle(key{kind: keyCancel}) != selectCanceled {
+		t.Error("cancel was not reported")
+	}
+}
+
+func TestSelectorMulti(t *testing.T) {
+	items := []string{"deepseek", "glm", "kimi-k2", "kimi-kfc"}
+	newMulti := func() *selector {
+		s := newSelector(items, 0)
+		s.multi = true
+		s.setHeight(10)
+		return s
+	}
+	runes := func(s *selector, text string) {
+		for _, r := range text {
+			s.handle(key{kind: keyRune, r: r})
+		}
+	}
+
+	s := newMulti()
+	if got := s.chosenItems(); !slices.Equal(got, []string{"deepseek"}) {
+		t.Errorf("chosenItems() without choices = %v, want the highlighted item", got)
+	}
+
+	// Space toggles, j and k move instead of filtering
+	runes(s, " jkj ")
+	if got := s.chosenItems(); !slices.Equal(got, []string{"deepseek", "glm"}) {
+		t.Errorf("chosenItems() = %v, want deepseek and glm", got)
+	}
+	runes(s, "jj ")
+	if lines := s.lines("Select:", "hint", nil); !strings.Contains(lines[len(lines)-1].text, "(3 chosen)") {
+		t.Errorf("hint = %q, want 3 chosen", lines[len(lines)-1].text)
+	}
+
+	// "/" starts filtering; Enter ends it and a then toggles the shown items
+	runes(s, "/kimi")
+	if len(s.matches) != 2 || !s.filtering {
+		t.Fatalf("filter matched %d items", len(s.matches))
+	}
+	if s.handle(key{kind: keyEnter}) != selectContinue || s.filtering {
+		t.Error("Enter while filtering should only end filtering")
+	}
+	runes(s, "a")
+	if got := s.chosenItems(); !slices.Equal(got, items) {
+		t.Errorf("chosenItems() = %v, want all items", got)
+	}
+	runes(s, "a")
+	if got := s.chosenItems(); !slices.Equal(got, []string{"deepseek", "glm"}) {
+		t.Errorf("chosenItems() = %v, want the kimi items unchosen", got)
+	}
+
+	// Esc while filtering clears the filter, and cancels otherwise
+	runes(s, "/x")
+	if s.handle(key{kind: keyCancel}) != selectContinue || len(s.matches) != len(items) {
+		t.Error("Esc while filtering should clear the filter")
+	}
+	if s.handle(key{kind: keyCancel}) != selectCanceled {
+		t.Error("cancel was not reported")
+	}
+
+	s = newMulti()
+	runes(s, "a")
+	if s.handle(key{kind: keyEnter}) != selectDone || len(s.chosenItems()) != len(items) {
+		t.Errorf("Enter = %v, want all items", s.chosenItems())
+	}
+	if newMulti().handle(key{kind: keyRune, r: 'q'}) != selectCanceled {
+		t.Error("q should cancel")
+	}
+}
@@ -0,0 +1,111 @@
+// Package tokencache runs token commands and reuses their output until it
+// expires, so that Claude Code's apiKeyHelper does not run them on every call
+package tokencache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/huangdijia/ccswitch/internal/atomicfile"
+)
+
+// entry is a cached token. The command is stored so that changing it
+// invalidates the token
+type entry struct {
+	Command string    `json:"command"`
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Dir returns the cache directory stored next to the profiles file
+func Dir(profilesPath string) string {
+	return filepath.Join(filepath.Dir(profilesPath), "tokens")
+}
+
+// Cache holds the tokens of several profiles in one directory
+type Cache struct {
+	Dir string
+	// Now returns the current time; nil means time.Now
+	Now func() time.Time
+}
+
+// Token returns the cached token of profile, running command for a new one
+// when there is none, it has expired or refresh is set
+func (c *Cache) Token(ctx context.Context, profile, command string, ttl time.Duration, refresh bool) (string, error) {
+	path := filepath.Join(c.Dir, url.PathEscape(profile)+".json")
+	now := c.now()
+
+	if !refresh {
+		if cached, err := load(path); err == nil && cached.Command == command && now.Before(cached.Expires) {
+			return cached.Token, nil
+		}
+	}
+
+	token, err := Run(ctx, command)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(entry{Command: command, Token: token, Expires: now.Add(ttl)})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return "", err
+	}
+	if err := atomicfile.WriteFileNoBackup(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to cache token: %w", err)
+	}
+	return token, nil
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func load(path string) (*entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Run runs command through the system shell and returns its trimmed output.
+// The command's stderr is passed through
+func Run(ctx context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("token command printed no token")
+	}
+	return token, nil
+}
@@ -0,0 +1,76 @@
+package tokencache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	command := "echo run >> " + counter + "; echo token-1"
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := &Cache{Dir: filepath.Join(dir, "tokens"), Now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	token, err := cache.Token(ctx, "corp/prod", command, time.Minute, false)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token != "token-1" || runs() != 1 {
+		t.Fatalf("Token() = %q after %d run(s), want token-1 after 1", token, runs())
+	}
+
+	if _, err := cache.Token(ctx, "corp/prod", command, time.Minute, false); err != nil || runs() != 1 {
+		t.Errorf("cached Token() ran the command again (%d runs, error %v)", runs(), err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.Token(ctx, "corp/prod", command, time.Minute, false); err != nil || runs() != 2 {
+		t.Errorf("expired Token() did not run the command (%d runs, error %v)", runs(), err)
+	}
+
+	if _, err := cache.Token(ctx, "corp/prod", command, time.Minute, true); err != nil || runs() != 3 {
+		t.Errorf("refreshed Token() did not run the command (%d runs, error %v)", runs(), err)
+	}
+
+	token, err = cache.Token(ctx, "corp/prod", "echo token-2", time.Minute, false)
+	if err != nil || token != "token-2" {
+		t.Errorf("Token() after a command change = %q, %v, want token-2", token, err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "tokens", "corp%2Fprod.json"))
+	if err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("cache file mode = %04o, want 0600", mode)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+
+	if _, err := Run(context.Background(), "exit 3"); err == nil {
+		t.Error("Run() of a failing command expected error")
+	}
+	if _, err := Run(context.Background(), "true"); err == nil {
+		t.Error("Run() of a command without output expected error")
+	}
+}